@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/system"
+
+	"github.com/lotas/docker-alerts/internal/config"
+	"github.com/lotas/docker-alerts/internal/containerd"
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/enrich"
+	"github.com/lotas/docker-alerts/internal/i18n"
+	"github.com/lotas/docker-alerts/internal/metrics"
+	"github.com/lotas/docker-alerts/internal/monitor"
+	"github.com/lotas/docker-alerts/internal/notifications"
+	"github.com/lotas/docker-alerts/internal/schedule"
+)
+
+// host is a monitored docker daemon or containerd, name is empty for the single local one
+type host struct {
+	name   string
+	source docker.EventSource
+	// client and enricher are nil without dockerd
+	client   *docker.Client
+	enricher *enrich.Enricher
+	watcher  *monitor.DaemonWatcher
+}
+
+// connectHosts creates a client for every --host, or for the environment when none is set
+func connectHosts(cfg *config.Config, redactor *docker.Redactor) ([]*host, error) {
+	newHost := func(name string, client *docker.Client) *host {
+		return &host{
+			name:   name,
+			source: client,
+			client: client,
+			enricher: enrich.New(client,
+				enrich.WithLogs(cfg.LogLines, redactor),
+				enrich.WithHealthLog(cfg.HealthLogEntries),
+			),
+		}
+	}
+
+	if len(cfg.Hosts) == 0 {
+		client, err := docker.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Docker client: %w", err)
+		}
+		return []*host{newHost("", client)}, nil
+	}
+
+	names := make([]string, 0, len(cfg.Hosts))
+	for name := range cfg.Hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var hosts []*host
+	for _, name := range names {
+		endpoint := cfg.Hosts[name]
+		if strings.HasPrefix(endpoint, "containerd://") {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				closeHosts(hosts)
+				return nil, fmt.Errorf("failed to connect host %s: %w", name, err)
+			}
+			hosts = append(hosts, &host{name: name, source: containerd.New(u.Path, u.Query().Get("namespace"))})
+			continue
+		}
+
+		client, err := docker.NewClientForHost(endpoint, cfg.HostTLSDir[name])
+		if err != nil {
+			closeHosts(hosts)
+			return nil, fmt.Errorf("failed to connect host %s: %w", name, err)
+		}
+		hosts = append(hosts, newHost(name, client))
+	}
+	return hosts, nil
+}
+
+// watch prepares the daemon watcher before run, the local daemon is named by its info
+func (h *host) watch(cfg *config.Config, info system.Info) {
+	label := h.name
+	if label == "" {
+		label = info.Name
+	}
+	h.watcher = monitor.NewDaemonWatcher(h.source, label, cfg.DaemonTimeout())
+}
+
+// streamStatus is the first host whose event stream is down
+func streamStatus(hosts []*host) func() error {
+	return func() error {
+		for _, h := range hosts {
+			if err := h.watcher.Err(); err != nil {
+				if h.name != "" {
+					return fmt.Errorf("%s: %w", h.name, err)
+				}
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// metricAction keeps the label set small, exec actions carry the whole command line
+func metricAction(action string) string {
+	if strings.HasPrefix(action, "exec_") {
+		action, _, _ = strings.Cut(action, ":")
+	}
+	return action
+}
+
+func closeHosts(hosts []*host) {
+	for _, h := range hosts {
+		h.source.Close()
+	}
+}
+
+// run watches the daemon until ctx is done, sending notifications tagged with the host name to out
+func (h *host) run(ctx context.Context, cfg *config.Config, info system.Info, summary *schedule.Schedule, out chan<- notifications.Event) {
+	dockerEvents := make(chan events.Message)
+	// synthetic events of the watchers and pollers
+	alerts := make(chan notifications.Event)
+
+	go h.watcher.Run(ctx, dockerEvents, alerts)
+
+	// remote hosts may be down at startup, the daemon watcher reports that
+	if info.ID == "" {
+		var ok bool
+		if info, ok = h.waitForInfo(ctx, cfg, dockerEvents, alerts, out); !ok {
+			return
+		}
+	}
+
+	if !h.forward(ctx, out, h.serverInfoEvent(ctx, "Server info", info)) {
+		return
+	}
+	if summary != nil {
+		go summary.Run(ctx, func(ctx context.Context) {
+			info, err := h.source.Info(ctx)
+			if err != nil {
+				// the daemon watcher reports unreachable hosts
+				fmt.Printf("Skipping the status report: %v\n", err)
+				return
+			}
+			h.forward(ctx, out, h.serverInfoEvent(ctx, "summary", info))
+		})
+	}
+	// pollers and the snapshot need the docker API
+	if h.client != nil {
+		if !cfg.NoSnapshot && !h.reportSnapshot(ctx, out) {
+			return
+		}
+		h.startPollers(ctx, cfg, info, alerts)
+	}
+
+	for {
+		select {
+		case event := <-dockerEvents:
+			if !h.handleEvent(ctx, cfg, event, out) {
+				return
+			}
+		case evt := <-alerts:
+			if !h.handleAlert(ctx, evt, out) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// infoRetryInterval is how often hosts that are down at startup are asked for their info
+var infoRetryInterval = 10 * time.Second
+
+// waitForInfo retries Info until the host answers, events and the alerts of the
+// daemon watcher, like the unreachable one, are forwarded meanwhile
+func (h *host) waitForInfo(ctx context.Context, cfg *config.Config, dockerEvents <-chan events.Message, alerts <-chan notifications.Event, out chan<- notifications.Event) (system.Info, bool) {
+	ticker := time.NewTicker(infoRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		info, err := h.source.Info(ctx)
+		if err == nil {
+			return info, true
+		}
+
+	wait:
+		for {
+			select {
+			case <-ticker.C:
+				break wait
+			case event := <-dockerEvents:
+				if !h.handleEvent(ctx, cfg, event, out) {
+					return info, false
+				}
+			case evt := <-alerts:
+				if !h.handleAlert(ctx, evt, out) {
+					return info, false
+				}
+			case <-ctx.Done():
+				return info, false
+			}
+		}
+	}
+}
+
+// handleEvent enriches and forwards a docker event that passes the filters
+func (h *host) handleEvent(ctx context.Context, cfg *config.Config, event events.Message, out chan<- notifications.Event) bool {
+	if h.enricher != nil {
+		h.enricher.Observe(event)
+	}
+	evt := notifications.NewEventFromDocker(event)
+	metrics.EventsReceived.WithLabelValues(evt.Type, metricAction(evt.Action)).Inc()
+	if !evt.ShouldNotify(cfg.Debug) {
+		metrics.EventsFiltered.WithLabelValues(evt.Type, metricAction(evt.Action)).Inc()
+		return true
+	}
+	if h.enricher != nil {
+		h.enricher.Enrich(ctx, &evt)
+	}
+	return h.forward(ctx, out, evt)
+}
+
+func (h *host) handleAlert(ctx context.Context, evt notifications.Event, out chan<- notifications.Event) bool {
+	metrics.EventsReceived.WithLabelValues(evt.Type, metricAction(evt.Action)).Inc()
+	return h.forward(ctx, out, evt)
+}
+
+// reportSnapshot sends the containers that are failing right now and marks them for recovery detection
+func (h *host) reportSnapshot(ctx context.Context, out chan<- notifications.Event) bool {
+	problems, err := monitor.Snapshot(ctx, h.client)
+	if err != nil {
+		fmt.Printf("Failed to check containers at startup: %v\n", err)
+		return true
+	}
+
+	for _, evt := range problems {
+		h.enricher.Seed(evt.Container)
+		h.enricher.Enrich(ctx, &evt)
+		if !h.forward(ctx, out, evt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *host) startPollers(ctx context.Context, cfg *config.Config, info system.Info, alerts chan<- notifications.Event) {
+	if cfg.StatsIntervalSeconds > 0 {
+		poller := monitor.NewStatsPoller(h.client, cfg.StatsInterval(), monitor.Thresholds{
+			CPUPercent:    cfg.CPUThreshold,
+			MemoryPercent: cfg.MemoryThreshold,
+			Restarts:      cfg.RestartThreshold,
+			Duration:      cfg.ThresholdDuration(),
+			Hysteresis:    cfg.ThresholdHysteresis,
+		})
+		go poller.Run(ctx, alerts)
+	}
+
+	// statfs only sees the local filesystem
+	if cfg.DiskIntervalSeconds > 0 && h.name == "" {
+		// the data root is a host path, mount it and set --disk-path when running in a container
+		path := cfg.DiskPath
+		if path == "" {
+			path = info.DockerRootDir
+		}
+		poller := monitor.NewDiskPoller(h.client, path, cfg.DiskInterval(), monitor.DiskThresholds{
+			MinFreePercent: cfg.DiskMinFreePercent,
+			MaxReclaimable: cfg.DiskMaxReclaimable(),
+			Hysteresis:     cfg.ThresholdHysteresis,
+			TopN:           cfg.DiskTopN,
+		})
+		go poller.Run(ctx, alerts)
+	}
+
+	// only managers can list tasks
+	if cfg.SwarmTasksIntervalSeconds > 0 && info.Swarm.ControlAvailable {
+		go monitor.NewTaskWatcher(h.client, cfg.SwarmTasksInterval()).Run(ctx, alerts)
+	}
+}
+
+func (h *host) forward(ctx context.Context, out chan<- notifications.Event, evt notifications.Event) bool {
+	evt.Host = h.name
+	select {
+	case out <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// serverInfoEvent reports the host, with container and disk details when it runs dockerd
+func (h *host) serverInfoEvent(ctx context.Context, eventType string, info system.Info) notifications.Event {
+	report := monitor.NewServerInfo(info)
+	if h.client != nil {
+		var err error
+		if report, err = monitor.ServerReport(ctx, h.client); err != nil {
+			fmt.Printf("Failed to build the server report: %v\n", err)
+			report = monitor.NewServerInfo(info)
+		}
+	}
+	if h.name == "" {
+		if uptime, err := monitor.HostUptime(); err == nil {
+			report.Uptime = int64(uptime.Seconds())
+		}
+	}
+
+	// Message is kept for custom templates, built-in ones render ServerInfo in the notifier's locale
+	return notifications.Event{
+		Type:       eventType,
+		Time:       time.Now().Unix(),
+		Message:    report.Text(i18n.English()),
+		ServerInfo: report,
+	}
+}
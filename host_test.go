@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/config"
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/monitor"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// downSource is a host that does not answer until up is set
+type downSource struct {
+	up atomic.Bool
+}
+
+func (d *downSource) StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*docker.EventStream, error) {
+	if !d.up.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return &docker.EventStream{Events: make(chan events.Message), Errors: make(chan error)}, nil
+}
+
+func (d *downSource) Info(ctx context.Context) (system.Info, error) {
+	if !d.up.Load() {
+		return system.Info{}, errors.New("connection refused")
+	}
+	return system.Info{ID: "lab-id", Name: "lab", ServerVersion: "v1.7.13"}, nil
+}
+
+func (d *downSource) Close() error {
+	return nil
+}
+
+func TestHost_UnreachableAtStartup(t *testing.T) {
+	infoRetryInterval = 50 * time.Millisecond
+	defer func() { infoRetryInterval = 10 * time.Second }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := &downSource{}
+	h := &host{name: "lab", source: source, watcher: monitor.NewDaemonWatcher(source, "lab", 0)}
+	out := make(chan notifications.Event)
+	go h.run(ctx, &config.Config{NoSnapshot: true}, system.Info{}, nil, out)
+
+	next := func() notifications.Event {
+		t.Helper()
+		select {
+		case evt := <-out:
+			return evt
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return notifications.Event{}
+		}
+	}
+
+	unreachable := next()
+	assert.Equal(t, "daemon", unreachable.Type)
+	assert.Equal(t, "unreachable", unreachable.Action)
+	assert.Equal(t, "lab", unreachable.Host)
+	assert.Contains(t, unreachable.DaemonError, "connection refused")
+
+	source.up.Store(true)
+	var types []string
+	for len(types) < 2 {
+		types = append(types, next().Type)
+	}
+	require.ElementsMatch(t, []string{"daemon", "Server info"}, types)
+}
@@ -2,16 +2,21 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/alexflint/go-arg"
 )
 
 type Config struct {
+	Notify []string `arg:"--notify,separate,env:DA_NOTIFY"`
+
 	TelegramToken  string `arg:"--telegram-token,env:DA_TELEGRAM_TOKEN"`
 	TelegramChatID string `arg:"--telegram-chat-id,env:DA_TELEGRAM_CHAT_ID"`
 
 	SlackWebhookURL string `arg:"--slack-webhook-url,env:DA_SLACK_WEBHOOK_URL"`
+	SlackToken      string `arg:"--slack-token,env:DA_SLACK_TOKEN"`
+	SlackChannel    string `arg:"--slack-channel,env:DA_SLACK_CHANNEL"`
 
 	EmailSMTPHost     string   `arg:"--email-smtp-host,env:DA_EMAIL_SMTP_HOST"`
 	EmailSMTPPort     int      `arg:"--email-smtp-port,env:DA_EMAIL_SMTP_PORT" default:"587"`
@@ -19,7 +24,64 @@ type Config struct {
 	EmailTo           []string `arg:"--email-to,env:DA_EMAIL_TO_ADDRESSES"`
 	EmailSMTPUsername string   `arg:"--email-username,env:DA_EMAIL_SMTP_USERNAME"`
 	EmailSMTPPassword string   `arg:"--email-password,env:DA_EMAIL_SMTP_PASSWORD"`
+	EmailAuth         string   `arg:"--email-auth,env:DA_EMAIL_SMTP_AUTH" default:"plain"`
+	EmailTLS          string   `arg:"--email-tls,env:DA_EMAIL_SMTP_TLS"`
+	EmailCAFile       string   `arg:"--email-ca-file,env:DA_EMAIL_SMTP_CA_FILE"`
+	EmailSkipVerify   bool     `arg:"--email-insecure-skip-verify,env:DA_EMAIL_SMTP_INSECURE_SKIP_VERIFY"`
+
+	ExecCommand        string `arg:"--exec-command,env:DA_EXEC_COMMAND"`
+	ExecTimeoutSeconds int    `arg:"--exec-timeout-seconds,env:DA_EXEC_TIMEOUT_SECONDS" default:"10"`
+	ExecConcurrency    int    `arg:"--exec-concurrency,env:DA_EXEC_CONCURRENCY" default:"1"`
+
+	FilePath          string `arg:"--file-path,env:DA_FILE_PATH"`
+	FileMaxSizeMB     int    `arg:"--file-max-size-mb,env:DA_FILE_MAX_SIZE_MB" default:"100"`
+	FileMaxAgeHours   int    `arg:"--file-max-age-hours,env:DA_FILE_MAX_AGE_HOURS" default:"24"`
+	FileMaxBackups    int    `arg:"--file-max-backups,env:DA_FILE_MAX_BACKUPS" default:"7"`
+	FileRetentionDays int    `arg:"--file-retention-days,env:DA_FILE_RETENTION_DAYS" default:"30"`
+
+	LogLines  int      `arg:"--log-lines,env:DA_LOG_LINES" default:"20"`
+	LogRedact []string `arg:"--log-redact,separate,env:DA_LOG_REDACT"`
+
+	HealthLogEntries int `arg:"--health-log-entries,env:DA_HEALTH_LOG_ENTRIES" default:"3"`
+
+	DaemonTimeoutSeconds int `arg:"--daemon-timeout-seconds,env:DA_DAEMON_TIMEOUT_SECONDS" default:"30"`
+
+	HeartbeatURL             string `arg:"--heartbeat-url,env:DA_HEARTBEAT_URL"`
+	HeartbeatFailURL         string `arg:"--heartbeat-fail-url,env:DA_HEARTBEAT_FAIL_URL"`
+	HeartbeatIntervalSeconds int    `arg:"--heartbeat-interval-seconds,env:DA_HEARTBEAT_INTERVAL_SECONDS" default:"60"`
+
+	HTTPAddr string `arg:"--http-addr,env:DA_HTTP_ADDR"`
+
+	StatsIntervalSeconds     int     `arg:"--stats-interval-seconds,env:DA_STATS_INTERVAL_SECONDS"`
+	CPUThreshold             float64 `arg:"--cpu-threshold,env:DA_CPU_THRESHOLD" default:"90"`
+	MemoryThreshold          float64 `arg:"--memory-threshold,env:DA_MEMORY_THRESHOLD" default:"90"`
+	RestartThreshold         int     `arg:"--restart-threshold,env:DA_RESTART_THRESHOLD" default:"3"`
+	ThresholdDurationSeconds int     `arg:"--threshold-duration-seconds,env:DA_THRESHOLD_DURATION_SECONDS" default:"60"`
+	ThresholdHysteresis      float64 `arg:"--threshold-hysteresis,env:DA_THRESHOLD_HYSTERESIS" default:"10"`
+
+	DiskIntervalSeconds  int     `arg:"--disk-interval-seconds,env:DA_DISK_INTERVAL_SECONDS"`
+	DiskPath             string  `arg:"--disk-path,env:DA_DISK_PATH"`
+	DiskMinFreePercent   float64 `arg:"--disk-min-free-percent,env:DA_DISK_MIN_FREE_PERCENT" default:"10"`
+	DiskMaxReclaimableGB float64 `arg:"--disk-max-reclaimable-gb,env:DA_DISK_MAX_RECLAIMABLE_GB" default:"20"`
+	DiskTopN             int     `arg:"--disk-top-n,env:DA_DISK_TOP_N" default:"5"`
+
+	EnableEvents []string `arg:"--enable-events,separate,env:DA_ENABLE_EVENTS"`
+
+	SwarmTasksIntervalSeconds int `arg:"--swarm-tasks-interval-seconds,env:DA_SWARM_TASKS_INTERVAL_SECONDS" default:"30"`
 
+	TemplatesDir string `arg:"--templates-dir,env:DA_TEMPLATES_DIR"`
+
+	Locale         string            `arg:"--locale,env:DA_LOCALE" default:"en"`
+	NotifierLocale map[string]string `arg:"--notifier-locale,env:DA_NOTIFIER_LOCALE"`
+
+	Hosts         map[string]string `arg:"--host,env:DA_HOSTS"`
+	HostTLSDir    map[string]string `arg:"--host-tls-dir,env:DA_HOST_TLS_DIR"`
+	NotifierHosts map[string]string `arg:"--notifier-hosts,env:DA_NOTIFIER_HOSTS"`
+
+	SummaryCron      string   `arg:"--summary-cron,env:DA_SUMMARY_CRON"`
+	SummaryNotifiers []string `arg:"--summary-notifiers,env:DA_SUMMARY_NOTIFIERS"`
+
+	NoSnapshot      bool `arg:"--no-snapshot,env:DA_NO_SNAPSHOT"`
 	NoDebounce      bool `arg:"--no-debounce,env:DA_NO_DEBOUNCE"`
 	DebounceSeconds int  `arg:"--debounce-seconds,env:DA_DEBOUNCE_SECONDS" default:"3"`
 	Debug           bool `arg:"--debug,env:DA_DEBUG"`
@@ -39,16 +101,68 @@ func (c *Config) DebounceDuration() time.Duration {
 	return time.Duration(c.DebounceSeconds) * time.Second
 }
 
+func (c *Config) ExecTimeout() time.Duration {
+	return time.Duration(c.ExecTimeoutSeconds) * time.Second
+}
+
+func (c *Config) FileMaxSize() int64 {
+	return int64(c.FileMaxSizeMB) * 1024 * 1024
+}
+
+func (c *Config) FileMaxAge() time.Duration {
+	return time.Duration(c.FileMaxAgeHours) * time.Hour
+}
+
+func (c *Config) FileRetention() time.Duration {
+	return time.Duration(c.FileRetentionDays) * 24 * time.Hour
+}
+
+func (c *Config) DaemonTimeout() time.Duration {
+	return time.Duration(c.DaemonTimeoutSeconds) * time.Second
+}
+
+func (c *Config) HeartbeatInterval() time.Duration {
+	return time.Duration(c.HeartbeatIntervalSeconds) * time.Second
+}
+
+func (c *Config) StatsInterval() time.Duration {
+	return time.Duration(c.StatsIntervalSeconds) * time.Second
+}
+
+func (c *Config) ThresholdDuration() time.Duration {
+	return time.Duration(c.ThresholdDurationSeconds) * time.Second
+}
+
+func (c *Config) DiskInterval() time.Duration {
+	return time.Duration(c.DiskIntervalSeconds) * time.Second
+}
+
+func (c *Config) DiskMaxReclaimable() int64 {
+	return int64(c.DiskMaxReclaimableGB * 1024 * 1024 * 1024)
+}
+
+func (c *Config) SwarmTasksInterval() time.Duration {
+	return time.Duration(c.SwarmTasksIntervalSeconds) * time.Second
+}
+
 func (c *Config) PrintValues() {
 	fmt.Println("Config values")
 	fmt.Println("-------------")
+	for _, n := range c.Notify {
+		scheme, _, _ := strings.Cut(n, "://")
+		fmt.Printf("Notify:            %s://***\n", scheme)
+	}
 	if c.TelegramToken != "" {
-		fmt.Printf("TelegramToken:     %s\n", c.TelegramToken[:15]+"***")
+		fmt.Printf("TelegramToken:     %s\n", maskPrefix(c.TelegramToken, 15))
 	}
 	fmt.Printf("TelegramChatID:    %s\n", c.TelegramChatID)
 	if c.SlackWebhookURL != "" {
-		fmt.Printf("SlackWebhookURL:   %s\n", c.SlackWebhookURL[:20]+"***")
+		fmt.Printf("SlackWebhookURL:   %s\n", maskPrefix(c.SlackWebhookURL, 20))
+	}
+	if c.SlackToken != "" {
+		fmt.Printf("SlackToken:        %s\n", maskPrefix(c.SlackToken, 9))
 	}
+	fmt.Printf("SlackChannel:      %s\n", c.SlackChannel)
 	fmt.Printf("EmailSMTPHost:     %s\n", c.EmailSMTPHost)
 	fmt.Printf("EmailSMTPPort:     %d\n", c.EmailSMTPPort)
 	fmt.Printf("EmailFrom:         %s\n", c.EmailFrom)
@@ -57,7 +171,64 @@ func (c *Config) PrintValues() {
 	if c.EmailSMTPPassword != "" {
 		fmt.Printf("EmailSMTPPassword: %s\n", "****")
 	}
+	fmt.Printf("EmailAuth:         %s\n", c.EmailAuth)
+	fmt.Printf("EmailTLS:          %s\n", c.EmailTLS)
+	fmt.Printf("EmailCAFile:       %s\n", c.EmailCAFile)
+	fmt.Printf("EmailSkipVerify:   %t\n", c.EmailSkipVerify)
+	if command := strings.Fields(c.ExecCommand); len(command) > 0 {
+		// arguments may hold tokens
+		fmt.Printf("ExecCommand:       %s ***\n", command[0])
+	}
+	fmt.Printf("ExecTimeout:       %ds\n", c.ExecTimeoutSeconds)
+	fmt.Printf("ExecConcurrency:   %d\n", c.ExecConcurrency)
+	fmt.Printf("FilePath:          %s\n", c.FilePath)
+	fmt.Printf("FileMaxSizeMB:     %d\n", c.FileMaxSizeMB)
+	fmt.Printf("FileMaxAgeHours:   %d\n", c.FileMaxAgeHours)
+	fmt.Printf("FileMaxBackups:    %d\n", c.FileMaxBackups)
+	fmt.Printf("FileRetentionDays: %d\n", c.FileRetentionDays)
+	fmt.Printf("LogLines:          %d\n", c.LogLines)
+	fmt.Printf("LogRedact:         %d patterns\n", len(c.LogRedact))
+	fmt.Printf("HealthLogEntries:  %d\n", c.HealthLogEntries)
+	fmt.Printf("DaemonTimeout:     %ds\n", c.DaemonTimeoutSeconds)
+	if c.HeartbeatURL != "" {
+		scheme, _, _ := strings.Cut(c.HeartbeatURL, "://")
+		fmt.Printf("HeartbeatURL:      %s://***\n", scheme)
+	}
+	fmt.Printf("HeartbeatInterval: %ds\n", c.HeartbeatIntervalSeconds)
+	fmt.Printf("HTTPAddr:          %s\n", c.HTTPAddr)
+	fmt.Printf("StatsInterval:     %ds\n", c.StatsIntervalSeconds)
+	fmt.Printf("CPUThreshold:      %.0f%%\n", c.CPUThreshold)
+	fmt.Printf("MemoryThreshold:   %.0f%%\n", c.MemoryThreshold)
+	fmt.Printf("RestartThreshold:  %d\n", c.RestartThreshold)
+	fmt.Printf("ThresholdDuration: %ds\n", c.ThresholdDurationSeconds)
+	fmt.Printf("Hysteresis:        %.0f%%\n", c.ThresholdHysteresis)
+	fmt.Printf("DiskInterval:      %ds\n", c.DiskIntervalSeconds)
+	fmt.Printf("DiskPath:          %s\n", c.DiskPath)
+	fmt.Printf("DiskMinFree:       %.0f%%\n", c.DiskMinFreePercent)
+	fmt.Printf("DiskReclaimable:   %.1fGB\n", c.DiskMaxReclaimableGB)
+	fmt.Printf("DiskTopN:          %d\n", c.DiskTopN)
+	fmt.Printf("EnableEvents:      %v\n", c.EnableEvents)
+	fmt.Printf("SwarmTasks:        %ds\n", c.SwarmTasksIntervalSeconds)
+	fmt.Printf("TemplatesDir:      %s\n", c.TemplatesDir)
+	fmt.Printf("Locale:            %s\n", c.Locale)
+	fmt.Printf("NotifierLocale:    %v\n", c.NotifierLocale)
+	for name := range c.Hosts {
+		fmt.Printf("Host:              %s\n", name)
+	}
+	fmt.Printf("HostTLSDir:        %v\n", c.HostTLSDir)
+	fmt.Printf("NotifierHosts:     %v\n", c.NotifierHosts)
+	fmt.Printf("SummaryCron:       %s\n", c.SummaryCron)
+	fmt.Printf("SummaryNotifiers:  %v\n", c.SummaryNotifiers)
 	fmt.Printf("NoDebounce:        %t\n", c.NoDebounce)
+	fmt.Printf("NoSnapshot:        %t\n", c.NoSnapshot)
 	fmt.Printf("DebounceSeconds:   %d\n", c.DebounceSeconds)
 	fmt.Printf("Debug:             %t\n", c.Debug)
 }
+
+// maskPrefix shows the first n characters of a secret, nothing of one that short
+func maskPrefix(secret string, n int) string {
+	if len(secret) <= n {
+		return "***"
+	}
+	return secret[:n] + "***"
+}
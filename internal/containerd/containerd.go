@@ -0,0 +1,281 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	containerd "github.com/containerd/containerd"
+	apievents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/containers"
+	cdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/typeurl/v2"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/system"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+)
+
+// DefaultNamespace is the namespace of nerdctl
+const DefaultNamespace = "default"
+
+const nerdctlNameLabel = "nerdctl/name"
+
+// oomKilledAttribute marks the exit after an OOM kill, notifications reads it like the enricher's OOM details
+const oomKilledAttribute = "oomKilled"
+
+// topics are the events that are turned into container events
+var topics = []string{"/tasks/start", "/tasks/exit", "/tasks/oom", "/containers/delete"}
+
+// client is the part of the containerd client used by Source
+type client interface {
+	Subscribe(ctx context.Context, filters ...string) (<-chan *cdevents.Envelope, <-chan error)
+	ContainerService() containers.Store
+	Version(ctx context.Context) (containerd.Version, error)
+	Server(ctx context.Context) (containerd.ServerInfo, error)
+	Close() error
+}
+
+// Source reads the events service of containerd over its socket and turns task events
+// into container events of the Docker API
+type Source struct {
+	address   string
+	namespace string
+	dial      func(address, namespace string) (client, error)
+
+	mu         sync.Mutex
+	client     client
+	containers map[string]containers.Container
+	// containers with an OOM kill since their last exit
+	oomKilled map[string]bool
+}
+
+var _ docker.EventSource = (*Source)(nil)
+
+// New watches the containerd socket at address, only events of namespace are reported.
+// The socket is connected on first use.
+func New(address, namespace string) *Source {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &Source{
+		address:    address,
+		namespace:  namespace,
+		dial:       dial,
+		containers: map[string]containers.Container{},
+		oomKilled:  map[string]bool{},
+	}
+}
+
+func dial(address, namespace string) (client, error) {
+	return containerd.New(address, containerd.WithDefaultNamespace(namespace))
+}
+
+// connect returns the client, dialing the socket when there is none yet
+func (s *Source) connect() (client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+	c, err := s.dial(s.address, s.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to containerd: %w", err)
+	}
+	s.client = c
+	return c, nil
+}
+
+// StreamEventsSince streams new events, containerd keeps no history so since is ignored
+func (s *Source) StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*docker.EventStream, error) {
+	c, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		subscription = append(subscription, fmt.Sprintf(`namespace==%s,topic==%q`, s.namespace, topic))
+	}
+	envelopes, subscribeErrs := c.Subscribe(ctx, subscription...)
+
+	msgs := make(chan events.Message)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			select {
+			case env := <-envelopes:
+				if env == nil {
+					continue
+				}
+				msg, ok := s.message(ctx, c, env)
+				if !ok {
+					continue
+				}
+				select {
+				case msgs <- msg:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-subscribeErrs:
+				if err == nil {
+					err = fmt.Errorf("containerd event subscription ended")
+				}
+				errs <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return &docker.EventStream{Events: msgs, Errors: errs}, nil
+}
+
+// message maps task and container events, false for the events that are not reported
+func (s *Source) message(ctx context.Context, c client, env *cdevents.Envelope) (events.Message, bool) {
+	if env.Namespace != s.namespace || env.Event == nil {
+		return events.Message{}, false
+	}
+
+	decoded, err := typeurl.UnmarshalAny(env.Event)
+	if err != nil {
+		fmt.Printf("Skipping containerd event %s: %v\n", env.Topic, err)
+		return events.Message{}, false
+	}
+
+	var action events.Action
+	var containerID string
+	attributes := map[string]string{}
+
+	switch e := decoded.(type) {
+	case *apievents.TaskStart:
+		action = events.ActionStart
+		containerID = e.ContainerID
+	case *apievents.TaskExit:
+		// exits of exec processes have their own ID
+		if e.ID != e.ContainerID {
+			return events.Message{}, false
+		}
+		action = events.ActionDie
+		containerID = e.ContainerID
+		attributes["exitCode"] = strconv.FormatUint(uint64(e.ExitStatus), 10)
+		s.mu.Lock()
+		if s.oomKilled[containerID] {
+			attributes[oomKilledAttribute] = "true"
+			delete(s.oomKilled, containerID)
+		}
+		s.mu.Unlock()
+	case *apievents.TaskOOM:
+		// the exit that follows is reported as OOM killed
+		action = events.ActionOOM
+		containerID = e.ContainerID
+		s.mu.Lock()
+		s.oomKilled[containerID] = true
+		s.mu.Unlock()
+	case *apievents.ContainerDelete:
+		action = events.ActionDestroy
+		containerID = e.ID
+	default:
+		return events.Message{}, false
+	}
+
+	info := s.container(ctx, c, containerID, action != events.ActionDestroy)
+	if action == events.ActionDestroy {
+		s.mu.Lock()
+		delete(s.containers, containerID)
+		delete(s.oomKilled, containerID)
+		s.mu.Unlock()
+	}
+
+	// nerdctl and nerdctl compose set the same labels as docker
+	for key, value := range info.Labels {
+		attributes[key] = value
+	}
+	attributes["name"] = info.Labels[nerdctlNameLabel]
+	if attributes["name"] == "" {
+		attributes["name"] = containerID
+	}
+	attributes["image"] = info.Image
+
+	return events.Message{
+		Type:     events.ContainerEventType,
+		Action:   action,
+		Status:   string(action),
+		ID:       containerID,
+		From:     info.Image,
+		Actor:    events.Actor{ID: containerID, Attributes: attributes},
+		Scope:    "local",
+		Time:     env.Timestamp.Unix(),
+		TimeNano: env.Timestamp.UnixNano(),
+	}, true
+}
+
+// container returns the cached labels and image of a container, looking them up when missing
+func (s *Source) container(ctx context.Context, c client, id string, lookup bool) containers.Container {
+	s.mu.Lock()
+	info, ok := s.containers[id]
+	s.mu.Unlock()
+	if ok || !lookup {
+		return info
+	}
+
+	info, err := c.ContainerService().Get(ctx, id)
+	if err != nil {
+		fmt.Printf("Failed to get containerd container %s: %v\n", id, err)
+		return info
+	}
+
+	s.mu.Lock()
+	s.containers[id] = info
+	s.mu.Unlock()
+	return info
+}
+
+// Info reports the version and UUID of the containerd server and the resources of this machine
+func (s *Source) Info(ctx context.Context) (system.Info, error) {
+	c, err := s.connect()
+	if err != nil {
+		return system.Info{}, err
+	}
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		return system.Info{}, fmt.Errorf("failed to get containerd version: %w", err)
+	}
+	server, err := c.Server(ctx)
+	if err != nil {
+		return system.Info{}, fmt.Errorf("failed to get containerd server info: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	info := system.Info{
+		ID:            server.UUID,
+		Name:          hostname,
+		ServerVersion: version.Version,
+		OSType:        runtime.GOOS,
+		Architecture:  runtime.GOARCH,
+		NCPU:          runtime.NumCPU(),
+	}
+
+	return info, nil
+}
+
+func (s *Source) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
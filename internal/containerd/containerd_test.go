@@ -0,0 +1,188 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	containerd "github.com/containerd/containerd"
+	apievents "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/containers"
+	cdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/typeurl/v2"
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// fakeClient replays envelopes like the events service of containerd
+type fakeClient struct {
+	envelopes []*cdevents.Envelope
+	filters   []string
+	store     *fakeStore
+	closed    bool
+}
+
+func (f *fakeClient) Subscribe(ctx context.Context, filters ...string) (<-chan *cdevents.Envelope, <-chan error) {
+	f.filters = filters
+	ch := make(chan *cdevents.Envelope, len(f.envelopes))
+	for _, env := range f.envelopes {
+		ch <- env
+	}
+	errs := make(chan error, 1)
+	go func() {
+		// like a closed connection once everything is read
+		for len(ch) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		errs <- fmt.Errorf("connection closed")
+	}()
+	return ch, errs
+}
+
+func (f *fakeClient) ContainerService() containers.Store {
+	return f.store
+}
+
+func (f *fakeClient) Version(ctx context.Context) (containerd.Version, error) {
+	return containerd.Version{Version: "v1.7.13", Revision: "7c3aca7a610df76212171d200ca3811ff6096eb8"}, nil
+}
+
+func (f *fakeClient) Server(ctx context.Context) (containerd.ServerInfo, error) {
+	return containerd.ServerInfo{UUID: "2f1c9d2e-4b6a-4f0e-9c1d-8a7b6c5d4e3f"}, nil
+}
+
+func (f *fakeClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+type fakeStore struct {
+	containers.Store
+	gets []string
+}
+
+func (f *fakeStore) Get(ctx context.Context, id string) (containers.Container, error) {
+	f.gets = append(f.gets, id)
+	return containers.Container{
+		ID:    id,
+		Image: "docker.io/library/nginx:1.27",
+		Labels: map[string]string{
+			"com.docker.compose.project": "shop",
+			"com.docker.compose.service": "web",
+			"nerdctl/name":               "shop-web-1",
+			"nerdctl/namespace":          "default",
+		},
+	}, nil
+}
+
+func envelope(t *testing.T, at time.Time, namespace, topic string, event interface{}) *cdevents.Envelope {
+	t.Helper()
+	any, err := typeurl.MarshalAny(event)
+	require.NoError(t, err)
+	return &cdevents.Envelope{Timestamp: at, Namespace: namespace, Topic: topic, Event: any}
+}
+
+func newTestSource(t *testing.T) (*Source, *fakeClient) {
+	at := time.Date(2024, 5, 29, 10, 0, 0, 0, time.UTC)
+	fake := &fakeClient{
+		store: &fakeStore{},
+		envelopes: []*cdevents.Envelope{
+			envelope(t, at.Add(1300*time.Millisecond), "default", "/tasks/start", &apievents.TaskStart{ContainerID: "a1b2c3", Pid: 4242}),
+			envelope(t, at.Add(2*time.Second), "k8s.io", "/tasks/start", &apievents.TaskStart{ContainerID: "other", Pid: 1}),
+			envelope(t, at.Add(time.Minute), "default", "/tasks/exit", &apievents.TaskExit{ContainerID: "a1b2c3", ID: "exec-1", Pid: 4300, ExitStatus: 1}),
+			envelope(t, at.Add(2*time.Minute), "default", "/tasks/oom", &apievents.TaskOOM{ContainerID: "a1b2c3"}),
+			envelope(t, at.Add(2*time.Minute), "default", "/tasks/exit", &apievents.TaskExit{ContainerID: "a1b2c3", ID: "a1b2c3", Pid: 4242, ExitStatus: 137}),
+			envelope(t, at.Add(3*time.Minute), "default", "/containers/delete", &apievents.ContainerDelete{ID: "a1b2c3"}),
+		},
+	}
+
+	source := New("/run/containerd/containerd.sock", "")
+	source.dial = func(address, namespace string) (client, error) {
+		assert.Equal(t, "/run/containerd/containerd.sock", address)
+		assert.Equal(t, "default", namespace)
+		return fake, nil
+	}
+	return source, fake
+}
+
+func TestSource_StreamEvents(t *testing.T) {
+	source, fake := newTestSource(t)
+
+	stream, err := source.StreamEventsSince(context.Background(), time.Time{})
+	require.NoError(t, err)
+	assert.Contains(t, fake.filters, `namespace==default,topic=="/tasks/exit"`)
+
+	var received []events.Message
+	for len(received) < 4 {
+		select {
+		case msg := <-stream.Events:
+			received = append(received, msg)
+		case err := <-stream.Errors:
+			t.Fatalf("stream ended after %d events: %v", len(received), err)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d events", len(received))
+		}
+	}
+	assert.ErrorContains(t, <-stream.Errors, "connection closed")
+
+	var actions []events.Action
+	for _, msg := range received {
+		actions = append(actions, msg.Action)
+	}
+	// other namespaces and exec exits are skipped
+	assert.Equal(t, []events.Action{"start", "oom", "die", "destroy"}, actions)
+
+	start := received[0]
+	assert.Equal(t, events.ContainerEventType, start.Type)
+	assert.Equal(t, "a1b2c3", start.Actor.ID)
+	assert.Equal(t, "shop-web-1", start.Actor.Attributes["name"])
+	assert.Equal(t, "docker.io/library/nginx:1.27", start.Actor.Attributes["image"])
+	assert.Equal(t, "shop", start.Actor.Attributes["com.docker.compose.project"])
+	assert.Equal(t, "web", start.Actor.Attributes["com.docker.compose.service"])
+	assert.Equal(t, time.Date(2024, 5, 29, 10, 0, 1, 300000000, time.UTC).UnixNano(), start.TimeNano)
+
+	die := received[2]
+	assert.Equal(t, "137", die.Actor.Attributes["exitCode"])
+	assert.Equal(t, "shop-web-1", die.Actor.Attributes["name"])
+
+	// the OOM kill is reported with the exit, containerd hosts have no enricher
+	assert.Equal(t, "true", die.Actor.Attributes["oomKilled"])
+	evt := notifications.NewEventFromDocker(die)
+	assert.True(t, evt.OOMKilled)
+	assert.True(t, evt.ShouldNotify(false))
+	assert.Empty(t, source.oomKilled)
+
+	// the container is looked up once and forgotten when deleted
+	assert.Equal(t, []string{"a1b2c3"}, fake.store.gets)
+	assert.Equal(t, "shop-web-1", received[3].Actor.Attributes["name"])
+	assert.Empty(t, source.containers)
+}
+
+func TestSource_Info(t *testing.T) {
+	source, fake := newTestSource(t)
+
+	info, err := source.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v1.7.13", info.ServerVersion)
+	assert.Equal(t, "2f1c9d2e-4b6a-4f0e-9c1d-8a7b6c5d4e3f", info.ID)
+	assert.NotZero(t, info.NCPU)
+
+	require.NoError(t, source.Close())
+	assert.True(t, fake.closed)
+}
+
+func TestSource_DialError(t *testing.T) {
+	source := New("/run/containerd/containerd.sock", "k8s.io")
+	source.dial = func(address, namespace string) (client, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	_, err := source.StreamEventsSince(context.Background(), time.Time{})
+	assert.ErrorContains(t, err, "failed to connect to containerd: connection refused")
+	require.NoError(t, source.Close())
+}
@@ -3,6 +3,8 @@ package docker
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"path/filepath"
 
 	"github.com/docker/docker/api/types/system"
 	"github.com/docker/docker/client"
@@ -13,7 +15,12 @@ type Client struct {
 }
 
 func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if socket := podmanSocket(); socket != "" {
+		opts = append(opts, client.WithHost(socket))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -21,20 +28,44 @@ func NewClient() (*Client, error) {
 	return &Client{cli: cli}, nil
 }
 
-func (c *Client) Info(ctx context.Context) (system.Info, string, error) {
-	info, err := c.cli.Info(ctx)
+// NewClientForHost connects to tcp://host:2376 using ca.pem, cert.pem and key.pem from tlsDir,
+// to ssh://user@host through the docker cli installed there, or to a unix:// socket of Docker or Podman
+func NewClientForHost(endpoint, tlsDir string) (*Client, error) {
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return info, "", err
+		return nil, fmt.Errorf("invalid docker host %q: %w", endpoint, err)
 	}
 
-	serverInfo := fmt.Sprintf("Docker version: %v\n", info.ServerVersion)
-	serverInfo += fmt.Sprintf("Docker host: %v\n", info.Name)
-	serverInfo += fmt.Sprintf("Type: %v\n", info.OSType)
-	serverInfo += fmt.Sprintf("Architecture: %v\n", info.Architecture)
-	serverInfo += fmt.Sprintf("CPUs: %v\n", info.NCPU)
-	serverInfo += fmt.Sprintf("Memory: %v MB\n", info.MemTotal/1024/1024)
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+	switch u.Scheme {
+	case "ssh":
+		opts = append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(sshDialer(u)),
+		)
+	case "tcp", "unix", "npipe":
+		opts = append(opts, client.WithHost(endpoint))
+		if tlsDir != "" {
+			opts = append(opts, client.WithTLSClientConfig(
+				filepath.Join(tlsDir, "ca.pem"),
+				filepath.Join(tlsDir, "cert.pem"),
+				filepath.Join(tlsDir, "key.pem"),
+			))
+		}
+	default:
+		return nil, fmt.Errorf("invalid docker host %q: unsupported scheme %q", endpoint, u.Scheme)
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client for %s: %w", endpoint, err)
+	}
+
+	return &Client{cli: cli}, nil
+}
 
-	return info, serverInfo, nil
+func (c *Client) Info(ctx context.Context) (system.Info, error) {
+	return c.cli.Info(ctx)
 }
 
 func (c *Client) Close() error {
@@ -3,10 +3,13 @@ package docker
 import (
 	"context"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/system"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,8 +17,16 @@ import (
 
 // mockDockerClient is a custom struct implementing necessary methods for testing
 type mockDockerClient struct {
-	infoFunc   func(ctx context.Context) (system.Info, error)
-	eventsFunc func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
+	infoFunc     func(ctx context.Context) (system.Info, error)
+	eventsFunc   func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
+	logsFunc     func(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+	inspectFunc  func(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	listFunc     func(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	statsFunc    func(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+	diskFunc     func(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
+	tasksFunc    func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
+	servicesFunc func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	nodesFunc    func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
 }
 
 func (m *mockDockerClient) Info(ctx context.Context) (system.Info, error) {
@@ -26,6 +37,38 @@ func (m *mockDockerClient) Events(ctx context.Context, options types.EventsOptio
 	return m.eventsFunc(ctx, options)
 }
 
+func (m *mockDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	return m.listFunc(ctx, options)
+}
+
+func (m *mockDockerClient) ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error) {
+	return m.statsFunc(ctx, containerID, stream)
+}
+
+func (m *mockDockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return m.inspectFunc(ctx, containerID)
+}
+
+func (m *mockDockerClient) ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+	return m.logsFunc(ctx, container, options)
+}
+
+func (m *mockDockerClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	return m.diskFunc(ctx, options)
+}
+
+func (m *mockDockerClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	return m.tasksFunc(ctx, options)
+}
+
+func (m *mockDockerClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	return m.servicesFunc(ctx, options)
+}
+
+func (m *mockDockerClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	return m.nodesFunc(ctx, options)
+}
+
 func (m *mockDockerClient) Close() error {
 	return nil
 }
@@ -54,27 +97,9 @@ func TestInfo(t *testing.T) {
 			cli: mockClient,
 		}
 
-		// Call the Info method
-		ctx := context.Background()
-		info, serverInfo, err := c.Info(ctx)
-
-		// Assertions
+		info, err := c.Info(context.Background())
 		require.NoError(t, err)
 		assert.Equal(t, mockInfo, info)
-
-		// Check serverInfo string contains expected information
-		expectedParts := []string{
-			"Docker version: 20.10.12",
-			"Docker host: docker-test-host",
-			"Type: linux",
-			"Architecture: x86_64",
-			"CPUs: 4",
-			"Memory: 8192 MB", // 8GB in MB
-		}
-
-		for _, part := range expectedParts {
-			assert.Contains(t, serverInfo, part)
-		}
 	})
 
 	t.Run("error retrieving info", func(t *testing.T) {
@@ -90,13 +115,19 @@ func TestInfo(t *testing.T) {
 			cli: mockClient,
 		}
 
-		// Call the Info method
-		ctx := context.Background()
-		_, serverInfo, err := c.Info(ctx)
-
-		// Assertions
+		_, err := c.Info(context.Background())
 		require.Error(t, err)
-		assert.Equal(t, "", serverInfo)
 		assert.Contains(t, err.Error(), "connection error")
 	})
 }
+
+func TestNewClientForHost(t *testing.T) {
+	for _, endpoint := range []string{"tcp://10.0.0.5:2376", "ssh://deploy@web1:2222", "unix:///var/run/docker.sock"} {
+		c, err := NewClientForHost(endpoint, "")
+		require.NoError(t, err, endpoint)
+		c.Close()
+	}
+
+	_, err := NewClientForHost("http://web1", "")
+	assert.ErrorContains(t, err, `unsupported scheme "http"`)
+}
@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// DiskItem is an image or a volume with its size in bytes
+type DiskItem struct {
+	Name string
+	Size int64
+}
+
+// DiskUsage summarizes "docker system df"
+type DiskUsage struct {
+	ImagesSize            int64
+	ImagesReclaimable     int64
+	BuildCacheSize        int64
+	BuildCacheReclaimable int64
+	VolumesSize           int64
+	// largest first
+	Images  []DiskItem
+	Volumes []DiskItem
+}
+
+// DiskUsage queries the space used by images, build cache and volumes, it can take a while on busy hosts
+func (c *Client) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	df, err := c.cli.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.ImageObject, types.VolumeObject, types.BuildCacheObject},
+	})
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	return diskUsageFromDF(df), nil
+}
+
+// diskUsageFromDF follows the calculation of "docker system df"
+func diskUsageFromDF(df types.DiskUsage) DiskUsage {
+	var usage DiskUsage
+
+	usage.ImagesSize = df.LayersSize
+	for _, img := range df.Images {
+		if img == nil {
+			continue
+		}
+		if img.Containers <= 0 {
+			// layers shared with used images are not freed by removing this one
+			reclaimable := img.Size
+			if img.SharedSize > 0 {
+				reclaimable -= img.SharedSize
+			}
+			usage.ImagesReclaimable += reclaimable
+		}
+		usage.Images = append(usage.Images, DiskItem{Name: imageName(img.RepoTags, img.ID), Size: img.Size})
+	}
+	if usage.ImagesSize == 0 {
+		for _, img := range usage.Images {
+			usage.ImagesSize += img.Size
+		}
+	}
+
+	for _, cache := range df.BuildCache {
+		if cache == nil {
+			continue
+		}
+		if !cache.Shared {
+			usage.BuildCacheSize += cache.Size
+		}
+		if !cache.InUse && !cache.Shared {
+			usage.BuildCacheReclaimable += cache.Size
+		}
+	}
+
+	for _, vol := range df.Volumes {
+		// -1 when the driver does not report usage
+		if vol == nil || vol.UsageData == nil || vol.UsageData.Size < 0 {
+			continue
+		}
+		usage.VolumesSize += vol.UsageData.Size
+		usage.Volumes = append(usage.Volumes, DiskItem{Name: vol.Name, Size: vol.UsageData.Size})
+	}
+
+	sortBySize(usage.Images)
+	sortBySize(usage.Volumes)
+
+	return usage
+}
+
+func imageName(tags []string, id string) string {
+	for _, tag := range tags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
+}
+
+func sortBySize(items []DiskItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Size > items[j].Size
+	})
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	c := &Client{cli: &mockDockerClient{
+		diskFunc: func(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+			assert.NotContains(t, options.Types, types.ContainerObject)
+			return types.DiskUsage{
+				LayersSize: 1500,
+				Images: []*image.Summary{
+					{ID: "sha256:0123456789abcdef", RepoTags: []string{"<none>:<none>"}, Size: 300, SharedSize: 100, Containers: 0},
+					{ID: "sha256:fedcba9876543210", RepoTags: []string{"postgres:16"}, Size: 1000, SharedSize: 100, Containers: 1},
+					{ID: "sha256:aaaaaaaaaaaaaaaa", RepoTags: []string{"redis:7"}, Size: 200, SharedSize: -1, Containers: 0},
+				},
+				BuildCache: []*types.BuildCache{
+					{ID: "a", Size: 50, InUse: true},
+					{ID: "b", Size: 70},
+					{ID: "c", Size: 30, Shared: true},
+				},
+				Volumes: []*volume.Volume{
+					{Name: "pgdata", UsageData: &volume.UsageData{Size: 4000}},
+					{Name: "cache", UsageData: &volume.UsageData{Size: 10}},
+					{Name: "nfs", UsageData: &volume.UsageData{Size: -1}},
+				},
+			}, nil
+		},
+	}}
+
+	usage, err := c.DiskUsage(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1500), usage.ImagesSize)
+	assert.Equal(t, int64(400), usage.ImagesReclaimable)
+	assert.Equal(t, int64(120), usage.BuildCacheSize)
+	assert.Equal(t, int64(70), usage.BuildCacheReclaimable)
+	assert.Equal(t, int64(4010), usage.VolumesSize)
+	assert.Equal(t, []DiskItem{{"postgres:16", 1000}, {"0123456789ab", 300}, {"redis:7", 200}}, usage.Images)
+	assert.Equal(t, []DiskItem{{"pgdata", 4000}, {"cache", 10}}, usage.Volumes)
+}
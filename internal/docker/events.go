@@ -2,6 +2,9 @@ package docker
 
 import (
 	"context"
+	"fmt"
+	"time"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
@@ -13,15 +16,45 @@ type EventStream struct {
 }
 
 func (c *Client) StreamEvents(ctx context.Context, filterArgs ...filters.Args) (*EventStream, error) {
+	return c.StreamEventsSince(ctx, time.Time{}, filterArgs...)
+}
+
+// StreamEventsSince replays events after since before streaming new ones, a zero since streams only new events.
+// Streaming stops when ctx is done.
+func (c *Client) StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*EventStream, error) {
 	var opts types.EventsOptions
 	if len(filterArgs) > 0 {
 		opts.Filters = filterArgs[0]
 	}
+	if !since.IsZero() {
+		opts.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
 
 	eventsChan, errorsChan := c.cli.Events(ctx, opts)
 
+	// Podman events are rewritten into the Docker form, the goroutine ends with ctx like the one of the docker client
+	normalized := make(chan events.Message)
+	go func() {
+		for {
+			select {
+			case msg, ok := <-eventsChan:
+				if !ok {
+					close(normalized)
+					return
+				}
+				select {
+				case normalized <- c.healthStatus(ctx, NormalizeEvent(msg)):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	return &EventStream{
-		Events: eventsChan,
+		Events: normalized,
 		Errors: errorsChan,
 	}, nil
 }
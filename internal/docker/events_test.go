@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
@@ -44,7 +45,6 @@ func TestStreamEvents(t *testing.T) {
 		defer close(actualMockedEventsChan)
 		defer close(actualMockedErrorsChan)
 
-		var expectedEventsChan <-chan events.Message = actualMockedEventsChan
 		var expectedErrorsChan <-chan error = actualMockedErrorsChan
 
 		mockAPIClient := &mockDockerEventsClient{
@@ -62,7 +62,13 @@ func TestStreamEvents(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, eventStream)
 
-		assert.Equal(t, expectedEventsChan, eventStream.Events, "Events channel does not match")
+		// podman events arrive in the docker form
+		go func() {
+			actualMockedEventsChan <- events.Message{Type: events.ContainerEventType, Action: "died", Actor: events.Actor{ID: "abc"}}
+		}()
+		msg := <-eventStream.Events
+		assert.Equal(t, events.ActionDie, msg.Action)
+		assert.Equal(t, "abc", msg.Actor.ID)
 		assert.Equal(t, expectedErrorsChan, eventStream.Errors, "Errors channel does not match")
 	})
 
@@ -72,7 +78,6 @@ func TestStreamEvents(t *testing.T) {
 		defer close(actualMockedEventsChan)
 		defer close(actualMockedErrorsChan)
 
-		var expectedEventsChan <-chan events.Message = actualMockedEventsChan
 		var expectedErrorsChan <-chan error = actualMockedErrorsChan
 
 		expectedFilters := filters.NewArgs()
@@ -92,7 +97,13 @@ func TestStreamEvents(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, eventStream)
 
-		assert.Equal(t, expectedEventsChan, eventStream.Events, "Events channel does not match")
+		// podman events arrive in the docker form
+		go func() {
+			actualMockedEventsChan <- events.Message{Type: events.ContainerEventType, Action: "died", Actor: events.Actor{ID: "abc"}}
+		}()
+		msg := <-eventStream.Events
+		assert.Equal(t, events.ActionDie, msg.Action)
+		assert.Equal(t, "abc", msg.Actor.ID)
 		assert.Equal(t, expectedErrorsChan, eventStream.Errors, "Errors channel does not match")
 	})
 
@@ -124,6 +135,18 @@ func TestStreamEvents(t *testing.T) {
 		require.NoError(t, err)
 
 	})
+
+	t.Run("event stream since a point in time", func(t *testing.T) {
+		mockAPIClient := &mockDockerEventsClient{
+			eventsFunc: func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+				assert.Equal(t, "1700000000.000000042", options.Since)
+				return nil, nil
+			},
+		}
+		c := &Client{cli: mockAPIClient}
+		_, err := c.StreamEventsSince(context.Background(), time.Unix(1700000000, 42))
+		require.NoError(t, err)
+	})
 }
 
 // It might be necessary to define a Close() method on mockDockerEventsClient
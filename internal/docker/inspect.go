@@ -0,0 +1,17 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+)
+
+func (c *Client) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	inspect, err := c.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return inspect, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	return inspect, nil
+}
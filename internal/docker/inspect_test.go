@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c := &Client{cli: &mockDockerClient{
+			inspectFunc: func(ctx context.Context, id string) (types.ContainerJSON, error) {
+				assert.Equal(t, "abc", id)
+				return types.ContainerJSON{
+					ContainerJSONBase: &types.ContainerJSONBase{ID: "abc", RestartCount: 2},
+				}, nil
+			},
+		}}
+
+		result, err := c.Inspect(context.Background(), "abc")
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.RestartCount)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := &Client{cli: &mockDockerClient{
+			inspectFunc: func(ctx context.Context, id string) (types.ContainerJSON, error) {
+				return types.ContainerJSON{}, fmt.Errorf("No such container: abc")
+			},
+		}}
+
+		_, err := c.Inspect(context.Background(), "abc")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "No such container")
+	})
+}
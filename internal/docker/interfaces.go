@@ -2,14 +2,37 @@ package docker
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/system"
 )
 
 type DockerAPIClient interface {
 	Info(ctx context.Context) (system.Info, error)
 	Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+	DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
+	TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
 	Close() error
 }
+
+// EventSource is a container runtime that produces events in the form of the Docker API,
+// implemented by Client and by runtimes without dockerd
+type EventSource interface {
+	StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*EventStream, error)
+	Info(ctx context.Context) (system.Info, error)
+	Close() error
+}
+
+var _ EventSource = (*Client)(nil)
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// logs of chatty containers are cut to keep notifications within api limits
+const maxLogBytes = 64 * 1024
+
+// TailLogs returns the last lines of the container's stdout and stderr
+func (c *Client) TailLogs(ctx context.Context, containerID string, lines int) (string, error) {
+	reader, err := c.cli.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read container logs: %w", err)
+	}
+
+	logs := demultiplex(data)
+	if len(logs) > maxLogBytes {
+		logs = logs[len(logs)-maxLogBytes:]
+		// drop the partial first line
+		if i := bytes.IndexByte(logs, '\n'); i >= 0 {
+			logs = logs[i+1:]
+		}
+	}
+
+	return strings.TrimRight(string(logs), "\n"), nil
+}
+
+// demultiplex strips the 8 byte stream headers docker adds to logs of
+// containers without a TTY: [stream, 0, 0, 0, size (big endian uint32)].
+// Output of TTY containers is returned as is.
+func demultiplex(data []byte) []byte {
+	if !isMultiplexed(data) {
+		return data
+	}
+
+	var out bytes.Buffer
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		out.Write(data[:size])
+		data = data[size:]
+	}
+
+	return out.Bytes()
+}
+
+func isMultiplexed(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	// stdin, stdout, stderr or systemerr
+	if data[0] > 3 {
+		return false
+	}
+	return data[1] == 0 && data[2] == 0 && data[3] == 0
+}
+
+// Redactor masks secrets in container logs before they are sent anywhere
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor compiles the patterns. When a pattern has a capture group
+// only the group is masked, e.g. `password=(\S+)` keeps "password=".
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+const redacted = "[REDACTED]"
+
+func (r *Redactor) Redact(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, redacted)
+			continue
+		}
+
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			groups := re.FindStringSubmatchIndex(match)
+			if len(groups) < 4 || groups[2] < 0 {
+				return redacted
+			}
+			return match[:groups[2]] + redacted + match[groups[3]:]
+		})
+	}
+	return s
+}
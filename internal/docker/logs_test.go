@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frame(stream byte, payload string) string {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return string(header) + payload
+}
+
+func TestTailLogs(t *testing.T) {
+	t.Run("multiplexed stream", func(t *testing.T) {
+		var options container.LogsOptions
+		c := &Client{cli: &mockDockerClient{
+			logsFunc: func(ctx context.Context, id string, opts container.LogsOptions) (io.ReadCloser, error) {
+				assert.Equal(t, "abc", id)
+				options = opts
+				return io.NopCloser(strings.NewReader(
+					frame(1, "starting\n") + frame(2, "panic: boom\n") + frame(2, "exit\n"),
+				)), nil
+			},
+		}}
+
+		logs, err := c.TailLogs(context.Background(), "abc", 20)
+		require.NoError(t, err)
+		assert.Equal(t, "starting\npanic: boom\nexit", logs)
+		assert.Equal(t, "20", options.Tail)
+		assert.True(t, options.ShowStdout && options.ShowStderr)
+	})
+
+	t.Run("tty output is not multiplexed", func(t *testing.T) {
+		c := &Client{cli: &mockDockerClient{
+			logsFunc: func(ctx context.Context, id string, opts container.LogsOptions) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("plain output\r\n")), nil
+			},
+		}}
+
+		logs, err := c.TailLogs(context.Background(), "abc", 5)
+		require.NoError(t, err)
+		assert.Equal(t, "plain output\r", logs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := &Client{cli: &mockDockerClient{
+			logsFunc: func(ctx context.Context, id string, opts container.LogsOptions) (io.ReadCloser, error) {
+				return nil, fmt.Errorf("No such container: abc")
+			},
+		}}
+
+		_, err := c.TailLogs(context.Background(), "abc", 5)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "No such container")
+	})
+}
+
+func TestDemultiplexTruncatedFrame(t *testing.T) {
+	data := frame(1, "complete\n") + frame(2, "cut off")
+	assert.Equal(t, "complete\ncut", string(demultiplex([]byte(data[:len(data)-4]))))
+}
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor([]string{`password=(\S+)`, `sk_live_[0-9a-zA-Z]+`})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"login password=[REDACTED] key [REDACTED] done",
+		r.Redact("login password=hunter2 key sk_live_abc123 done"),
+	)
+
+	_, err = NewRedactor([]string{"("})
+	require.Error(t, err)
+}
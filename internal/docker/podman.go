@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// PodEventType is emitted by the Docker compatible API of Podman
+const PodEventType events.Type = "pod"
+
+const (
+	podmanComposeProjectLabel = "io.podman.compose.project"
+	podmanComposeServiceLabel = "io.podman.compose.service"
+	composeProjectLabel       = "com.docker.compose.project"
+	composeServiceLabel       = "com.docker.compose.service"
+)
+
+// NormalizeEvent rewrites the events of Podman into the form Docker uses, Docker events are returned unchanged
+func NormalizeEvent(msg events.Message) events.Message {
+	if msg.Type != events.ContainerEventType {
+		return msg
+	}
+
+	attributes := msg.Actor.Attributes
+	// podman sets podId on every container event, empty outside of pods
+	_, podman := attributes["podId"]
+	podman = podman || attributes["containerExitCode"] != "" || msg.Action == "died"
+	if !podman {
+		return msg
+	}
+
+	// never modify the attributes of the original message
+	normalized := make(map[string]string, len(attributes)+2)
+	for key, value := range attributes {
+		normalized[key] = value
+	}
+
+	// the libpod endpoint and Podman before 4.0 report died, the compat endpoint die
+	if msg.Action == "died" {
+		msg.Action = events.ActionDie
+	}
+
+	if code, ok := attributes["containerExitCode"]; ok && normalized["exitCode"] == "" {
+		normalized["exitCode"] = code
+	}
+	if normalized[composeProjectLabel] == "" && attributes[podmanComposeProjectLabel] != "" {
+		normalized[composeProjectLabel] = attributes[podmanComposeProjectLabel]
+	}
+	if normalized[composeServiceLabel] == "" && attributes[podmanComposeServiceLabel] != "" {
+		normalized[composeServiceLabel] = attributes[podmanComposeServiceLabel]
+	}
+
+	msg.Actor.Attributes = normalized
+	msg.Status = string(msg.Action)
+	return msg
+}
+
+// healthStatus completes a Podman health_status event with the status of the container,
+// Podman sends the status in a field that the docker client does not decode
+func (c *Client) healthStatus(ctx context.Context, msg events.Message) events.Message {
+	if msg.Type != events.ContainerEventType || msg.Action != "health_status" {
+		return msg
+	}
+
+	inspect, err := c.Inspect(ctx, msg.Actor.ID)
+	if err != nil || inspect.State == nil || inspect.State.Health == nil {
+		return msg
+	}
+	msg.Action = events.Action("health_status: " + inspect.State.Health.Status)
+	msg.Status = string(msg.Action)
+	return msg
+}
+
+// podmanSocket finds the socket of a rootful or rootless Podman service
+// when DOCKER_HOST is not set and there is no Docker socket
+func podmanSocket() string {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return ""
+	}
+	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+		return ""
+	}
+
+	candidates := []string{"/run/podman/podman.sock"}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return "unix://" + path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayFixture streams recorded /events messages like the docker client does
+func replayFixture(t *testing.T, path string) *mockDockerEventsClient {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var messages []events.Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg events.Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		messages = append(messages, msg)
+	}
+	require.NoError(t, scanner.Err())
+
+	return &mockDockerEventsClient{
+		eventsFunc: func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+			msgs := make(chan events.Message, len(messages))
+			for _, msg := range messages {
+				msgs <- msg
+			}
+			close(msgs)
+			return msgs, make(chan error)
+		},
+	}
+}
+
+// testdata/podman_events.jsonl has the shape of the compat /events endpoint of Podman 5,
+// see ConvertToEntitiesEvent and the compat events handler of podman
+func TestStreamEvents_PodmanFixture(t *testing.T) {
+	cli := replayFixture(t, "testdata/podman_events.jsonl")
+	var inspected []string
+	cli.inspectFunc = func(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+		inspected = append(inspected, containerID)
+		return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			State: &types.ContainerState{Health: &types.Health{Status: "unhealthy"}},
+		}}, nil
+	}
+	c := &Client{cli: cli}
+
+	stream, err := c.StreamEvents(context.Background())
+	require.NoError(t, err)
+
+	var received []events.Message
+	for msg := range stream.Events {
+		received = append(received, msg)
+	}
+	require.Len(t, received, 6)
+
+	created := received[0]
+	assert.Equal(t, events.Action("create"), created.Action)
+	assert.Equal(t, "shop", created.Actor.Attributes["com.docker.compose.project"])
+	assert.Equal(t, "web", created.Actor.Attributes["com.docker.compose.service"])
+
+	health := received[2]
+	assert.Equal(t, events.Action("health_status: unhealthy"), health.Action)
+	assert.Equal(t, "health_status: unhealthy", health.Status)
+	assert.Equal(t, []string{health.Actor.ID}, inspected, "only health events are inspected")
+
+	died := received[3]
+	assert.Equal(t, events.ActionDie, died.Action)
+	assert.Equal(t, "137", died.Actor.Attributes["exitCode"])
+	assert.Equal(t, int64(1717000061400000000), died.TimeNano)
+
+	pod := received[4]
+	assert.Equal(t, PodEventType, pod.Type)
+	assert.Equal(t, "pod_shop", pod.Actor.Attributes["name"])
+
+	member := received[5]
+	assert.Equal(t, events.ActionDie, member.Action)
+	assert.Equal(t, "1", member.Actor.Attributes["exitCode"])
+	assert.Empty(t, member.Actor.Attributes["com.docker.compose.project"])
+}
+
+func TestNormalizeEvent_KeepsOriginal(t *testing.T) {
+	attributes := map[string]string{"name": "api", "containerExitCode": "1"}
+	msg := events.Message{Type: events.ContainerEventType, Action: "died", Actor: events.Actor{ID: "abc", Attributes: attributes}}
+
+	normalized := NormalizeEvent(msg)
+	assert.Equal(t, "1", normalized.Actor.Attributes["exitCode"])
+	assert.NotContains(t, attributes, "exitCode")
+	assert.Equal(t, events.Action("died"), msg.Action)
+
+	// docker events pass through untouched
+	docker := events.Message{Type: events.ContainerEventType, Action: events.ActionDie, Actor: events.Actor{
+		ID: "abc", Attributes: map[string]string{"name": "job", "exitCode": "1", "com.docker.compose.project": "batch"},
+	}}
+	assert.Equal(t, docker, NormalizeEvent(docker))
+}
+
+func TestPodmanSocket(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.5:2376")
+	assert.Empty(t, podmanSocket())
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshDialer tunnels the API through "docker system dial-stdio" on the remote host,
+// the same way the docker cli connects to ssh:// hosts
+func sshDialer(endpoint *url.URL) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	// never prompt for a password, keys or an agent are required
+	args := []string{"-o", "BatchMode=yes"}
+	if port := endpoint.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	target := endpoint.Hostname()
+	if endpoint.User != nil {
+		target = endpoint.User.Username() + "@" + target
+	}
+	args = append(args, "--", target, "docker", "system", "dial-stdio")
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// not bound to ctx, the dial context ends while the connection is still in use
+		cmd := exec.Command("ssh", args...)
+		return newCommandConn(cmd)
+	}
+}
+
+// ssh explains failures on stderr, the end of it is kept for the connection error
+const maxStderr = 4096
+
+// commandConn is a net.Conn over the stdin and stdout of a process
+type commandConn struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	stderr   *tailBuffer
+	once     sync.Once
+	waitOnce sync.Once
+}
+
+func newCommandConn(cmd *exec.Cmd) (*commandConn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh stdout: %w", err)
+	}
+	stderr := &tailBuffer{limit: maxStderr}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %w", err)
+	}
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, stderr: stderr}, nil
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	n, err := c.stdout.Read(b)
+	if err == io.EOF {
+		// the process exited, e.g. the host key or the key was rejected
+		c.wait()
+		if message := strings.TrimSpace(c.stderr.String()); message != "" {
+			return n, fmt.Errorf("ssh: %s", message)
+		}
+	}
+	return n, err
+}
+
+// wait reaps the process, after which the whole stderr is buffered
+func (c *commandConn) wait() {
+	c.waitOnce.Do(func() {
+		c.cmd.Wait()
+	})
+}
+
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+func (c *commandConn) Close() error {
+	c.once.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr{}
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr{}
+}
+
+// deadlines are not supported by pipes, the http client relies on contexts instead
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.limit {
+		t.buf = t.buf[len(t.buf)-t.limit:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandConn(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	conn, err := newCommandConn(exec.Command("cat"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	require.NoError(t, conn.Close())
+	// closing twice is fine
+	assert.NoError(t, conn.Close())
+}
+
+func TestCommandConn_Stderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	conn, err := newCommandConn(exec.Command("sh", "-c", "echo 'Permission denied (publickey).' >&2; exit 255"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = io.ReadAll(conn)
+	assert.EqualError(t, err, "ssh: Permission denied (publickey).")
+}
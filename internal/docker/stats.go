@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Usage is a point in time resource usage of a container
+type Usage struct {
+	CPUPercent    float64
+	MemoryUsage   uint64
+	MemoryLimit   uint64
+	MemoryPercent float64
+}
+
+// RunningContainers lists running and restarting containers
+func (c *Client) RunningContainers(ctx context.Context) ([]types.Container, error) {
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("status", "running"),
+			filters.Arg("status", "restarting"),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containers, nil
+}
+
+// Containers lists all containers including stopped ones
+func (c *Client) Containers(ctx context.Context) ([]types.Container, error) {
+	containers, err := c.cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containers, nil
+}
+
+// Usage takes a single stats sample, docker fills the previous CPU sample for the delta
+func (c *Client) Usage(ctx context.Context, containerID string) (Usage, error) {
+	reader, err := c.cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return Usage{}, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	return usageFromStats(stats), nil
+}
+
+// usageFromStats follows the calculation of "docker stats"
+func usageFromStats(stats container.StatsResponse) Usage {
+	var usage Usage
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	// page cache can be reclaimed, it is not counted as used
+	memory := stats.MemoryStats
+	usage.MemoryUsage = memory.Usage
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if inactive, ok := memory.Stats[key]; ok && inactive < memory.Usage {
+			usage.MemoryUsage = memory.Usage - inactive
+			break
+		}
+	}
+	usage.MemoryLimit = memory.Limit
+	if memory.Limit > 0 {
+		usage.MemoryPercent = float64(usage.MemoryUsage) / float64(memory.Limit) * 100
+	}
+
+	return usage
+}
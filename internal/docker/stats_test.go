@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	body := `{
+		"cpu_stats": {"cpu_usage": {"total_usage": 400000000}, "system_cpu_usage": 2000000000, "online_cpus": 4},
+		"precpu_stats": {"cpu_usage": {"total_usage": 200000000}, "system_cpu_usage": 1000000000},
+		"memory_stats": {"usage": 600, "limit": 1000, "stats": {"inactive_file": 100}}
+	}`
+
+	c := &Client{cli: &mockDockerClient{
+		statsFunc: func(ctx context.Context, id string, stream bool) (container.StatsResponseReader, error) {
+			assert.Equal(t, "abc", id)
+			assert.False(t, stream)
+			return container.StatsResponseReader{Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}}
+
+	usage, err := c.Usage(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.InDelta(t, 80.0, usage.CPUPercent, 0.001)
+	assert.Equal(t, uint64(500), usage.MemoryUsage)
+	assert.Equal(t, uint64(1000), usage.MemoryLimit)
+	assert.InDelta(t, 50.0, usage.MemoryPercent, 0.001)
+}
+
+func TestRunningContainers(t *testing.T) {
+	c := &Client{cli: &mockDockerClient{
+		listFunc: func(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+			statuses := options.Filters.Get("status")
+			sort.Strings(statuses)
+			assert.Equal(t, []string{"restarting", "running"}, statuses)
+			return []types.Container{{ID: "abc"}}, nil
+		},
+	}}
+
+	containers, err := c.RunningContainers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, containers, 1)
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Tasks lists the tasks of all services, only available on swarm managers
+func (c *Client) Tasks(ctx context.Context) ([]swarm.Task, error) {
+	tasks, err := c.cli.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// ServiceNames maps service IDs to names
+func (c *Client) ServiceNames(ctx context.Context) (map[string]string, error) {
+	services, err := c.cli.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	names := make(map[string]string, len(services))
+	for _, service := range services {
+		names[service.ID] = service.Spec.Name
+	}
+	return names, nil
+}
+
+// NodeNames maps node IDs to hostnames
+func (c *Client) NodeNames(ctx context.Context) (map[string]string, error) {
+	nodes, err := c.cli.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	names := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Description.Hostname
+		if node.Spec.Name != "" {
+			names[node.ID] = node.Spec.Name
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,33 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwarmNames(t *testing.T) {
+	c := &Client{cli: &mockDockerClient{
+		servicesFunc: func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{{ID: "s1", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_api"}}}}, nil
+		},
+		nodesFunc: func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{
+				{ID: "n1", Description: swarm.NodeDescription{Hostname: "worker-1"}},
+				{ID: "n2", Description: swarm.NodeDescription{Hostname: "ip-10-0-0-2"}, Spec: swarm.NodeSpec{Annotations: swarm.Annotations{Name: "db"}}},
+			}, nil
+		},
+	}}
+
+	services, err := c.ServiceNames(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"s1": "shop_api"}, services)
+
+	nodes, err := c.NodeNames(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"n1": "worker-1", "n2": "db"}, nodes)
+}
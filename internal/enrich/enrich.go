@@ -0,0 +1,347 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+const composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+
+// entries of containers whose destroy event was missed are dropped after this
+const cacheTTL = 24 * time.Hour
+
+// cached inspect data is refreshed after this even without a state change
+const refreshAfter = 5 * time.Minute
+
+// actions after which the cached state of a container is outdated
+var stateChanges = map[events.Action]bool{
+	events.ActionStart:   true,
+	events.ActionRestart: true,
+	events.ActionDie:     true,
+	events.ActionOOM:     true,
+	events.ActionPause:   true,
+	events.ActionUnPause: true,
+	events.ActionRename:  true,
+	events.ActionUpdate:  true,
+}
+
+// docker emits "oom" right before the "die" of a killed container
+const oomWindow = time.Minute
+
+// Source is the part of the docker client used for enrichment
+type Source interface {
+	Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	TailLogs(ctx context.Context, containerID string, lines int) (string, error)
+}
+
+type cacheEntry struct {
+	inspect   types.ContainerJSON
+	fetchedAt time.Time
+	// set by Observe when the container changed state since fetchedAt
+	stale bool
+}
+
+// Enricher fills Event fields from container inspect data, log tails and healthchecks.
+// Inspect results are cached by container ID until the container changes state
+// or refreshAfter passes, a destroy event drops the entry.
+type Enricher struct {
+	source     Source
+	logLines   int
+	redactor   *docker.Redactor
+	healthLogs int
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	ooms  map[string]time.Time
+	// containers that exited with an error or turned unhealthy, for recovery detection
+	failing map[string]bool
+	now     func() time.Time
+}
+
+type Option func(*Enricher)
+
+// WithLogs attaches the last lines of output to die and unhealthy events
+func WithLogs(lines int, redactor *docker.Redactor) Option {
+	return func(e *Enricher) {
+		e.logLines = lines
+		e.redactor = redactor
+	}
+}
+
+// WithHealthLog attaches the last healthcheck results to unhealthy events
+func WithHealthLog(entries int) Option {
+	return func(e *Enricher) {
+		e.healthLogs = entries
+	}
+}
+
+func New(source Source, opts ...Option) *Enricher {
+	e := &Enricher{
+		source:  source,
+		cache:   map[string]cacheEntry{},
+		ooms:    map[string]time.Time{},
+		failing: map[string]bool{},
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Observe keeps the cache in sync with the docker event stream, remembers
+// oom events for the following die and failed containers, it is called for every event
+func (e *Enricher) Observe(msg events.Message) {
+	if msg.Type != events.ContainerEventType {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if entry, ok := e.cache[msg.Actor.ID]; ok && (stateChanges[msg.Action] || strings.HasPrefix(string(msg.Action), "health_status")) {
+		entry.stale = true
+		e.cache[msg.Actor.ID] = entry
+	}
+
+	switch msg.Action {
+	case events.ActionDestroy:
+		delete(e.cache, msg.Actor.ID)
+		delete(e.ooms, msg.Actor.ID)
+		delete(e.failing, msg.Actor.ID)
+	case events.ActionOOM:
+		e.ooms[msg.Actor.ID] = e.now()
+	case events.ActionDie:
+		if code := msg.Actor.Attributes["exitCode"]; code != "" && code != "0" {
+			e.failing[msg.Actor.ID] = true
+		}
+	case events.ActionHealthStatusUnhealthy:
+		e.failing[msg.Actor.ID] = true
+	}
+}
+
+// Seed marks containers found failing at startup, so that their recovery is reported
+func (e *Enricher) Seed(containerIDs ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, id := range containerIDs {
+		e.failing[id] = true
+	}
+}
+
+// Enrich adds container details to a notified event, failures are logged and ignored
+func (e *Enricher) Enrich(ctx context.Context, evt *notifications.Event) {
+	if evt.ConnectedContainer != "" {
+		// network and volume events only carry the container ID
+		if inspect, err := e.inspect(ctx, evt.ConnectedContainer); err == nil && inspect.ContainerJSONBase != nil {
+			evt.ConnectedName = strings.TrimPrefix(inspect.Name, "/")
+		}
+	}
+
+	if evt.Type != "container" || evt.Container == "" {
+		return
+	}
+
+	inspect, err := e.inspect(ctx, evt.Container)
+	if err != nil {
+		fmt.Printf("Failed to inspect %s: %v\n", evt.Name, err)
+	} else {
+		applyInspect(evt, inspect, e.healthLogs)
+	}
+	evt.Recovered = e.recovered(evt, hasHealthcheck(inspect))
+
+	if evt.Action == "die" && e.takeOOM(evt.Container) {
+		// the container may be gone before inspect tells us
+		evt.OOMKilled = true
+	}
+
+	e.attachLogs(ctx, evt)
+}
+
+// recovered is true for the first healthy event of a failed container, or its start when it has no healthcheck
+func (e *Enricher) recovered(evt *notifications.Event, healthcheck bool) bool {
+	switch evt.Action {
+	case string(events.ActionHealthStatusHealthy):
+	case string(events.ActionStart):
+		if healthcheck {
+			return false
+		}
+	default:
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	failed := e.failing[evt.Container]
+	delete(e.failing, evt.Container)
+	return failed
+}
+
+func hasHealthcheck(inspect types.ContainerJSON) bool {
+	if inspect.Config == nil || inspect.Config.Healthcheck == nil {
+		return false
+	}
+	test := inspect.Config.Healthcheck.Test
+	return len(test) > 0 && test[0] != "NONE"
+}
+
+func (e *Enricher) takeOOM(containerID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	at, ok := e.ooms[containerID]
+	delete(e.ooms, containerID)
+	for id, other := range e.ooms {
+		if e.now().Sub(other) > oomWindow {
+			delete(e.ooms, id)
+		}
+	}
+
+	return ok && e.now().Sub(at) <= oomWindow
+}
+
+func (e *Enricher) inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[containerID]
+	e.mu.Unlock()
+
+	if ok && !cached.stale && e.now().Sub(cached.fetchedAt) < refreshAfter {
+		return cached.inspect, nil
+	}
+
+	inspect, err := e.source.Inspect(ctx, containerID)
+	if err != nil {
+		if ok {
+			// containers started with --rm are often gone by the time their die event arrives
+			return cached.inspect, nil
+		}
+		return inspect, err
+	}
+
+	e.mu.Lock()
+	e.cache[containerID] = cacheEntry{inspect: inspect, fetchedAt: e.now()}
+	e.expireLocked()
+	e.mu.Unlock()
+
+	return inspect, nil
+}
+
+func (e *Enricher) expireLocked() {
+	for id, entry := range e.cache {
+		if e.now().Sub(entry.fetchedAt) > cacheTTL {
+			delete(e.cache, id)
+		}
+	}
+}
+
+func (e *Enricher) attachLogs(ctx context.Context, evt *notifications.Event) {
+	if e.logLines <= 0 {
+		return
+	}
+	if evt.Action != "die" && evt.Action != "health_status: unhealthy" {
+		return
+	}
+
+	logs, err := e.source.TailLogs(ctx, evt.Container, e.logLines)
+	if err != nil {
+		fmt.Printf("Failed to get logs of %s: %v\n", evt.Name, err)
+		return
+	}
+
+	if e.redactor != nil {
+		logs = e.redactor.Redact(logs)
+	}
+	evt.Logs = logs
+}
+
+func applyInspect(evt *notifications.Event, inspect types.ContainerJSON, healthLogs int) {
+	if inspect.ContainerJSONBase != nil {
+		evt.RestartCount = inspect.RestartCount
+		evt.ImageDigest = inspect.Image
+		if inspect.HostConfig != nil {
+			evt.RestartPolicy = string(inspect.HostConfig.RestartPolicy.Name)
+			evt.MemoryLimit = inspect.HostConfig.Memory
+		}
+		if state := inspect.State; state != nil {
+			evt.OOMKilled = state.OOMKilled
+			evt.StartedAt = parseTime(state.StartedAt)
+			evt.FinishedAt = parseTime(state.FinishedAt)
+			if evt.Action == "health_status: unhealthy" && healthLogs > 0 {
+				applyHealth(evt, state.Health, healthLogs)
+			}
+		}
+	}
+
+	if inspect.Config != nil {
+		evt.Hostname = inspect.Config.Hostname
+		evt.WorkingDir = inspect.Config.Labels[composeWorkingDirLabel]
+	}
+
+	if inspect.NetworkSettings != nil {
+		evt.IPAddresses = nil
+		networks := make([]string, 0, len(inspect.NetworkSettings.Networks))
+		for name := range inspect.NetworkSettings.Networks {
+			networks = append(networks, name)
+		}
+		sort.Strings(networks)
+		for _, name := range networks {
+			if endpoint := inspect.NetworkSettings.Networks[name]; endpoint != nil && endpoint.IPAddress != "" {
+				evt.IPAddresses = append(evt.IPAddresses, endpoint.IPAddress)
+			}
+		}
+	}
+
+	evt.Volumes = nil
+	for _, mount := range inspect.Mounts {
+		source := mount.Name
+		if source == "" {
+			source = mount.Source
+		}
+		evt.Volumes = append(evt.Volumes, source+":"+mount.Destination)
+	}
+}
+
+func applyHealth(evt *notifications.Event, health *types.Health, entries int) {
+	if health == nil {
+		return
+	}
+
+	results := health.Log
+	if len(results) > entries {
+		results = results[len(results)-entries:]
+	}
+
+	evt.HealthFailingStreak = health.FailingStreak
+	evt.HealthLog = nil
+	for _, result := range results {
+		evt.HealthLog = append(evt.HealthLog, notifications.HealthCheck{
+			Start:    result.Start.Unix(),
+			End:      result.End.Unix(),
+			ExitCode: result.ExitCode,
+			Output:   strings.TrimSpace(result.Output),
+		})
+	}
+}
+
+// parseTime converts inspect timestamps, docker uses "0001-01-01T00:00:00Z" for never
+func parseTime(value string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
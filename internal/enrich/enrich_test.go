@@ -0,0 +1,245 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+type fakeSource struct {
+	inspect  types.ContainerJSON
+	err      error
+	logs     string
+	inspects int
+}
+
+func (f *fakeSource) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	f.inspects++
+	return f.inspect, f.err
+}
+
+func (f *fakeSource) TailLogs(ctx context.Context, containerID string, lines int) (string, error) {
+	return f.logs, nil
+}
+
+func sampleInspect() types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:           "abc",
+			Image:        "sha256:4f2a1c9e",
+			RestartCount: 3,
+			HostConfig:   &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped}},
+			State: &types.ContainerState{
+				OOMKilled:  true,
+				StartedAt:  "2023-11-14T22:13:20.123456789Z",
+				FinishedAt: "0001-01-01T00:00:00Z",
+				Health: &types.Health{
+					FailingStreak: 4,
+					Log: []*types.HealthcheckResult{
+						{ExitCode: 0, Output: "ok"},
+						{ExitCode: 1, Output: "refused\n"},
+						{ExitCode: 1, Output: "timeout\n"},
+					},
+				},
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: "volume", Name: "pgdata", Destination: "/var/lib/postgresql/data"},
+			{Type: "bind", Source: "/srv/config", Destination: "/config"},
+		},
+		Config: &container.Config{
+			Hostname: "db-1",
+			Labels:   map[string]string{composeWorkingDirLabel: "/srv/shop"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"shop_default": {IPAddress: "172.18.0.2"},
+				"monitoring":   {IPAddress: "172.19.0.5"},
+			},
+		},
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	redactor, err := docker.NewRedactor([]string{`password=(\S+)`})
+	require.NoError(t, err)
+
+	source := &fakeSource{inspect: sampleInspect(), logs: "password=secret\npanic"}
+	enricher := New(source, WithLogs(20, redactor), WithHealthLog(2))
+
+	evt := notifications.Event{Type: "container", Action: "health_status: unhealthy", Container: "abc", Name: "db"}
+	enricher.Enrich(context.Background(), &evt)
+
+	assert.Equal(t, "unless-stopped", evt.RestartPolicy)
+	assert.Equal(t, 3, evt.RestartCount)
+	assert.True(t, evt.OOMKilled)
+	assert.Equal(t, int64(1700000000), evt.StartedAt)
+	assert.Zero(t, evt.FinishedAt)
+	assert.Equal(t, "sha256:4f2a1c9e", evt.ImageDigest)
+	assert.Equal(t, "db-1", evt.Hostname)
+	assert.Equal(t, []string{"172.19.0.5", "172.18.0.2"}, evt.IPAddresses)
+	assert.Equal(t, []string{"pgdata:/var/lib/postgresql/data", "/srv/config:/config"}, evt.Volumes)
+	assert.Equal(t, "/srv/shop", evt.WorkingDir)
+
+	assert.Equal(t, 4, evt.HealthFailingStreak)
+	require.Len(t, evt.HealthLog, 2)
+	assert.Equal(t, "timeout", evt.HealthLog[1].Output)
+
+	assert.Equal(t, "password=[REDACTED]\npanic", evt.Logs)
+
+	t.Run("start events get no logs or health", func(t *testing.T) {
+		evt := notifications.Event{Type: "container", Action: "start", Container: "abc"}
+		enricher.Enrich(context.Background(), &evt)
+		assert.Empty(t, evt.Logs)
+		assert.Empty(t, evt.HealthLog)
+		assert.Equal(t, 3, evt.RestartCount)
+	})
+
+	t.Run("non container events are skipped", func(t *testing.T) {
+		inspects := source.inspects
+		evt := notifications.Event{Type: "network", Action: "create", Name: "shop_default"}
+		enricher.Enrich(context.Background(), &evt)
+		assert.Equal(t, inspects, source.inspects)
+	})
+
+	t.Run("connected containers are named", func(t *testing.T) {
+		source.inspect.Name = "/db"
+		evt := notifications.Event{Type: "network", Action: "connect", Name: "shop_default", ConnectedContainer: "abc", Time: time.Now().Unix()}
+		enricher.Enrich(context.Background(), &evt)
+		assert.Equal(t, "db", evt.ConnectedName)
+		assert.Empty(t, evt.RestartPolicy)
+	})
+}
+
+func TestEnrich_Cache(t *testing.T) {
+	source := &fakeSource{inspect: sampleInspect()}
+	enricher := New(source)
+	now := time.Unix(1700000100, 0)
+	enricher.now = func() time.Time { return now }
+
+	// like the host event loop: every event is observed, notified ones are enriched
+	enrich := func(action events.Action) {
+		enricher.Observe(events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: "abc"}})
+		evt := notifications.Event{Type: "container", Action: string(action), Container: "abc", Time: now.Unix()}
+		enricher.Enrich(context.Background(), &evt)
+	}
+
+	enrich(events.ActionCreate)
+	assert.Equal(t, 1, source.inspects)
+
+	// no state change
+	enrich(events.ActionExecStart)
+	enrich(events.ActionAttach)
+	assert.Equal(t, 1, source.inspects)
+
+	enrich(events.ActionStart)
+	assert.Equal(t, 2, source.inspects)
+	enrich(events.ActionHealthStatusUnhealthy)
+	assert.Equal(t, 3, source.inspects)
+	enrich(events.ActionDie)
+	assert.Equal(t, 4, source.inspects)
+
+	now = now.Add(refreshAfter)
+	enrich(events.ActionExecStart)
+	assert.Equal(t, 5, source.inspects)
+
+	enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionDestroy, Actor: events.Actor{ID: "abc"}})
+	enrich(events.ActionExecStart)
+	assert.Equal(t, 6, source.inspects)
+
+	t.Run("falls back to cached data for removed containers", func(t *testing.T) {
+		source.err = fmt.Errorf("No such container: abc")
+
+		enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionDie, Actor: events.Actor{ID: "abc"}})
+		evt := notifications.Event{Type: "container", Action: "die", Container: "abc", Time: now.Unix()}
+		enricher.Enrich(context.Background(), &evt)
+		assert.Equal(t, 7, source.inspects)
+		assert.Equal(t, 3, evt.RestartCount)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		source.err = nil
+		now = now.Add(cacheTTL + time.Minute)
+		enricher.cache["other"] = cacheEntry{fetchedAt: now}
+		enricher.cache["abc"] = cacheEntry{fetchedAt: now.Add(-cacheTTL - time.Second)}
+		enricher.expireLocked()
+		assert.Len(t, enricher.cache, 1)
+	})
+}
+
+func TestEnrich_OOM(t *testing.T) {
+	inspect := sampleInspect()
+	inspect.State.OOMKilled = false
+	inspect.HostConfig.Memory = 512 * 1024 * 1024
+	source := &fakeSource{inspect: inspect}
+	enricher := New(source)
+
+	enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionOOM, Actor: events.Actor{ID: "abc"}})
+
+	evt := notifications.Event{Type: "container", Action: "die", Container: "abc", ExitCode: "137"}
+	enricher.Enrich(context.Background(), &evt)
+	assert.True(t, evt.OOMKilled)
+	assert.Equal(t, int64(512*1024*1024), evt.MemoryLimit)
+
+	// the oom is consumed by the first die
+	evt = notifications.Event{Type: "container", Action: "die", Container: "abc", ExitCode: "137"}
+	enricher.Enrich(context.Background(), &evt)
+	assert.False(t, evt.OOMKilled)
+
+	t.Run("stale oom events are ignored", func(t *testing.T) {
+		now := time.Now()
+		enricher.now = func() time.Time { return now }
+		enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionOOM, Actor: events.Actor{ID: "abc"}})
+
+		now = now.Add(oomWindow + time.Second)
+		evt := notifications.Event{Type: "container", Action: "die", Container: "abc", ExitCode: "137"}
+		enricher.Enrich(context.Background(), &evt)
+		assert.False(t, evt.OOMKilled)
+	})
+}
+
+func TestEnrich_Recovered(t *testing.T) {
+	source := &fakeSource{inspect: sampleInspect()}
+	enricher := New(source)
+	ctx := context.Background()
+
+	enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionDie, Actor: events.Actor{
+		ID: "abc", Attributes: map[string]string{"exitCode": "0"},
+	}})
+	evt := notifications.Event{Type: "container", Action: "start", Container: "abc"}
+	enricher.Enrich(ctx, &evt)
+	assert.False(t, evt.Recovered, "clean exits are not failures")
+
+	enricher.Observe(events.Message{Type: events.ContainerEventType, Action: events.ActionHealthStatusUnhealthy, Actor: events.Actor{ID: "abc"}})
+	evt = notifications.Event{Type: "container", Action: "health_status: healthy", Container: "abc"}
+	enricher.Enrich(ctx, &evt)
+	assert.True(t, evt.Recovered)
+
+	evt = notifications.Event{Type: "container", Action: "health_status: healthy", Container: "abc"}
+	enricher.Enrich(ctx, &evt)
+	assert.False(t, evt.Recovered, "only the first healthy event is a recovery")
+
+	t.Run("containers seeded at startup", func(t *testing.T) {
+		source.inspect.Config.Healthcheck = &container.HealthConfig{Test: []string{"CMD", "true"}}
+		enricher.Seed("abc")
+
+		evt := notifications.Event{Type: "container", Action: "start", Container: "abc"}
+		enricher.Enrich(ctx, &evt)
+		assert.False(t, evt.Recovered, "containers with a healthcheck recover when healthy")
+
+		evt = notifications.Event{Type: "container", Action: "health_status: healthy", Container: "abc"}
+		enricher.Enrich(ctx, &evt)
+		assert.True(t, evt.Recovered)
+	})
+}
@@ -0,0 +1,141 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// healthchecks.io keeps up to 100kB of a ping body, Uptime Kuma gets the message in the URL
+// so it is kept much shorter
+const maxErrorLength = 10000
+
+// Heartbeat is a dead man's switch: it pings an external monitor while the event stream
+// is healthy and reports the error when it is not, the monitor alerts when pings stop
+type Heartbeat struct {
+	url      string
+	failURL  string
+	interval time.Duration
+	status   func() error
+	client   *http.Client
+}
+
+type Option func(*Heartbeat)
+
+// WithFailURL overrides the URL pinged while the event stream is down
+func WithFailURL(failURL string) Option {
+	return func(h *Heartbeat) {
+		h.failURL = failURL
+	}
+}
+
+// New pings pingURL every interval while status returns nil. Failures go to pingURL with "/fail"
+// appended like healthchecks.io expects, or with status=down for Uptime Kuma push URLs.
+func New(pingURL string, interval time.Duration, status func() error, opts ...Option) (*Heartbeat, error) {
+	u, err := url.Parse(pingURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid heartbeat url %q", pingURL)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid heartbeat interval %s: must be positive", interval)
+	}
+
+	h := &Heartbeat{
+		url:      pingURL,
+		interval: interval,
+		status:   status,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+// Run pings right away and then every interval until ctx is done
+func (h *Heartbeat) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := h.ping(ctx); err != nil {
+			fmt.Printf("Heartbeat failed: %v\n", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Heartbeat) ping(ctx context.Context) error {
+	streamErr := h.status()
+	if streamErr == nil {
+		return h.send(ctx, http.MethodGet, h.url, "")
+	}
+
+	message := truncate(streamErr.Error(), maxErrorLength)
+	target := h.failURL
+	if target == "" {
+		target = failURL(h.url, message)
+	}
+	return h.send(ctx, http.MethodPost, target, message)
+}
+
+// truncate cuts s to at most n bytes without splitting a rune
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// failURL appends "/fail" to the path, Uptime Kuma push URLs get status=down and the message instead
+func failURL(pingURL, message string) string {
+	u, err := url.Parse(pingURL)
+	if err != nil {
+		return pingURL
+	}
+
+	query := u.Query()
+	if query.Has("status") {
+		query.Set("status", "down")
+		query.Set("msg", message)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/fail"
+	return u.String()
+}
+
+func (h *Heartbeat) send(ctx context.Context, method, target, body string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("heartbeat returned status code %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ping struct {
+	method, uri, body string
+}
+
+func newServer(t *testing.T) (*httptest.Server, func() []ping) {
+	var mu sync.Mutex
+	var pings []ping
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pings = append(pings, ping{r.Method, r.URL.RequestURI(), string(body)})
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []ping {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]ping(nil), pings...)
+	}
+}
+
+func TestHeartbeat_Ping(t *testing.T) {
+	server, pings := newServer(t)
+	var streamErr error
+	h, err := New(server.URL+"/ping/abc", time.Minute, func() error { return streamErr })
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, h.ping(ctx))
+	streamErr = fmt.Errorf("web1: unexpected EOF")
+	require.NoError(t, h.ping(ctx))
+
+	assert.Equal(t, []ping{
+		{"GET", "/ping/abc", ""},
+		{"POST", "/ping/abc/fail", "web1: unexpected EOF"},
+	}, pings())
+}
+
+func TestHeartbeat_UptimeKuma(t *testing.T) {
+	server, pings := newServer(t)
+	h, err := New(server.URL+"/api/push/token?status=up&msg=OK", time.Minute, func() error { return fmt.Errorf("daemon down") })
+	require.NoError(t, err)
+
+	require.NoError(t, h.ping(context.Background()))
+	assert.Equal(t, []ping{{"POST", "/api/push/token?msg=daemon+down&status=down", "daemon down"}}, pings())
+}
+
+func TestHeartbeat_FailURL(t *testing.T) {
+	server, pings := newServer(t)
+	h, err := New(server.URL+"/up", time.Minute, func() error { return fmt.Errorf("down") }, WithFailURL(server.URL+"/down"))
+	require.NoError(t, err)
+
+	require.NoError(t, h.ping(context.Background()))
+	assert.Equal(t, "/down", pings()[0].uri)
+}
+
+func TestHeartbeat_Run(t *testing.T) {
+	server, pings := newServer(t)
+	h, err := New(server.URL, 10*time.Millisecond, func() error { return nil })
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(pings()) >= 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	_, err := New("hc-ping.com/abc", time.Minute, nil)
+	assert.ErrorContains(t, err, "invalid heartbeat url")
+}
+
+func TestNew_InvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err := New("https://hc-ping.com/abc", interval, nil)
+		assert.ErrorContains(t, err, "invalid heartbeat interval")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	// "ж" is two bytes
+	assert.Equal(t, "a", truncate("aжb", 2))
+	assert.Equal(t, "aж", truncate("aжb", 3))
+}
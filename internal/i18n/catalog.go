@@ -0,0 +1,146 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Catalogs are flat "key": "message" JSON files, messages with arguments
+// are fmt format strings. Exit code descriptions follow
+// https://tldp.org/LDP/abs/html/exitcodes.html and
+// https://docs.docker.com/engine/containers/run/#exit-status
+//
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+const DefaultLocale = "en"
+
+type Catalog struct {
+	locale   string
+	messages map[string]string
+	fallback *Catalog
+}
+
+var english = mustLoad(DefaultLocale)
+
+// English is the built-in catalog every other locale falls back to
+func English() *Catalog {
+	return english
+}
+
+// Load returns the catalog for a locale such as "ru" or "ru_RU.UTF-8",
+// an empty locale means English
+func Load(locale string) (*Catalog, error) {
+	lang := normalize(locale)
+	if lang == "" || lang == DefaultLocale {
+		return english, nil
+	}
+
+	messages, err := readCatalog(lang)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported locale %q, available: %s", locale, strings.Join(Locales(), ", "))
+	}
+
+	return &Catalog{locale: lang, messages: messages, fallback: english}, nil
+}
+
+// Locales lists the shipped catalogs
+func Locales() []string {
+	entries, _ := catalogFiles.ReadDir("catalogs")
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func (c *Catalog) Locale() string {
+	return c.locale
+}
+
+// Lookup returns the message for key, falling back to English
+func (c *Catalog) Lookup(key string) (string, bool) {
+	if message, ok := c.messages[key]; ok {
+		return message, true
+	}
+	if c.fallback != nil {
+		return c.fallback.Lookup(key)
+	}
+	return "", false
+}
+
+// T translates key and formats it with args, unknown keys are returned as is
+func (c *Catalog) T(key string, args ...any) string {
+	message, ok := c.Lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Duration formats d with whole days, hours, minutes and seconds, e.g. "1h2m" or "1 ч 2 мин"
+func (c *Catalog) Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, c.T("duration.days", days))
+	}
+	if hours > 0 {
+		parts = append(parts, c.T("duration.hours", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, c.T("duration.minutes", minutes))
+	}
+	if seconds > 0 || len(parts) == 0 {
+		parts = append(parts, c.T("duration.seconds", seconds))
+	}
+
+	return strings.Join(parts, c.T("duration.separator"))
+}
+
+func normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	// "ru_RU.UTF-8" and "ru-RU" both mean "ru"
+	if i := strings.IndexAny(locale, "_-."); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "c" || locale == "posix" {
+		return DefaultLocale
+	}
+	return locale
+}
+
+func readCatalog(lang string) (map[string]string, error) {
+	data, err := catalogFiles.ReadFile(path.Join("catalogs", lang+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse %s catalog: %w", lang, err)
+	}
+	return messages, nil
+}
+
+func mustLoad(lang string) *Catalog {
+	messages, err := readCatalog(lang)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load built-in catalog: %v", err))
+	}
+	return &Catalog{locale: lang, messages: messages}
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	for _, locale := range []string{"ru", "ru-RU", "ru_RU.UTF-8", " RU "} {
+		catalog, err := Load(locale)
+		require.NoError(t, err, locale)
+		assert.Equal(t, "ru", catalog.Locale())
+	}
+
+	for _, locale := range []string{"", "en", "en_US.UTF-8", "C"} {
+		catalog, err := Load(locale)
+		require.NoError(t, err, locale)
+		assert.Same(t, English(), catalog)
+	}
+
+	_, err := Load("xx")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "en, ru")
+}
+
+func TestCatalog_T(t *testing.T) {
+	ru, err := Load("ru")
+	require.NoError(t, err)
+
+	assert.Equal(t, "остановка", ru.T("action.die"))
+	assert.Equal(t, "Немедленное завершение SIGKILL", ru.T("exit_code.137"))
+	assert.Equal(t, "Контейнеров остановлено: 3", ru.T("summary.containers", 3, "остановлено"))
+	assert.Equal(t, "no.such.key", ru.T("no.such.key"))
+
+	// missing translations fall back to English
+	ru.messages = map[string]string{}
+	assert.Equal(t, "Server info", ru.T("info.title"))
+}
+
+func TestCatalog_Duration(t *testing.T) {
+	ru, err := Load("ru")
+	require.NoError(t, err)
+
+	assert.Equal(t, "0s", English().Duration(0))
+	assert.Equal(t, "42s", English().Duration(42*time.Second))
+	assert.Equal(t, "1h2m", English().Duration(time.Hour+2*time.Minute))
+	assert.Equal(t, "1 ч 2 мин 3 с", ru.Duration(time.Hour+2*time.Minute+3*time.Second))
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	for _, locale := range Locales() {
+		catalog, err := Load(locale)
+		require.NoError(t, err)
+
+		for key := range catalog.messages {
+			_, ok := English().messages[key]
+			assert.True(t, ok, "%s: %q is not in the English catalog", locale, key)
+		}
+		for key := range English().messages {
+			_, ok := catalog.messages[key]
+			assert.True(t, ok, "%s: %q is not translated", locale, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics of the whole process, served by Handler
+var (
+	EventsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_events_received_total",
+		Help: "Events received from the container runtimes and pollers.",
+	}, []string{"type", "action"})
+	EventsFiltered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_events_filtered_total",
+		Help: "Events dropped because their type or action is not notified.",
+	}, []string{"type", "action"})
+	// debounced events are accepted when they are queued, their sends are counted per notifier
+	EventsAccepted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_events_accepted_total",
+		Help: "Events the notifiers accepted for sending.",
+	}, []string{"type", "action"})
+	EventsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_events_failed_total",
+		Help: "Events the notifiers returned an error for when they were handed over.",
+	}, []string{"type", "action"})
+	NotifierDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "docker_alerts_notifier_send_duration_seconds",
+		Help:    "Time taken by a notifier to send.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"notifier"})
+	NotifierFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_notifier_failures_total",
+		Help: "Failed sends per notifier.",
+	}, []string{"notifier"})
+	DebouncerBuffered = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "docker_alerts_debouncer_buffered_events",
+		Help: "Events waiting in the debouncer.",
+	})
+	StreamReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_alerts_stream_reconnects_total",
+		Help: "Event stream reconnects per host.",
+	}, []string{"host"})
+
+	// Registry has the metrics above and those of the Go runtime and the process
+	Registry = prometheus.NewRegistry()
+)
+
+func init() {
+	Registry.MustRegister(
+		EventsReceived, EventsFiltered, EventsAccepted, EventsFailed,
+		NotifierDuration, NotifierFailures,
+		DebouncerBuffered, StreamReconnects,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	EventsReceived.WithLabelValues("container", "start").Inc()
+	EventsReceived.WithLabelValues("container", "start").Inc()
+	EventsReceived.WithLabelValues("container", `health_status: "un\healthy"`).Inc()
+	DebouncerBuffered.Set(3)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(EventsReceived.WithLabelValues("container", "start")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(EventsReceived.WithLabelValues("container", "stop")))
+
+	require.NoError(t, testutil.GatherAndCompare(Registry, strings.NewReader(`# HELP docker_alerts_events_received_total Events received from the container runtimes and pollers.
+# TYPE docker_alerts_events_received_total counter
+docker_alerts_events_received_total{action="health_status: \"un\\healthy\"",type="container"} 1
+docker_alerts_events_received_total{action="start",type="container"} 2
+docker_alerts_events_received_total{action="stop",type="container"} 0
+# HELP docker_alerts_debouncer_buffered_events Events waiting in the debouncer.
+# TYPE docker_alerts_debouncer_buffered_events gauge
+docker_alerts_debouncer_buffered_events 3
+`), "docker_alerts_events_received_total", "docker_alerts_debouncer_buffered_events"))
+
+	problems, err := testutil.GatherAndLint(Registry)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/system"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/metrics"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+var errStreamClosed = errors.New("event stream closed")
+
+// DaemonSource is the part of the docker client used by the daemon watcher
+type DaemonSource interface {
+	StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*docker.EventStream, error)
+	Info(ctx context.Context) (system.Info, error)
+}
+
+// DaemonWatcher keeps the event stream open across daemon restarts. When the stream
+// breaks it pings the daemon, alerts once it has been unreachable for longer than
+// the grace period and resumes the stream from the last received event.
+type DaemonWatcher struct {
+	source DaemonSource
+	host   string
+	grace  time.Duration
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+
+	mu  sync.Mutex
+	err error
+}
+
+func NewDaemonWatcher(source DaemonSource, host string, grace time.Duration) *DaemonWatcher {
+	return &DaemonWatcher{
+		source:     source,
+		host:       host,
+		grace:      grace,
+		minBackoff: time.Second,
+		maxBackoff: 30 * time.Second,
+		now:        time.Now,
+	}
+}
+
+// Run forwards docker events to out and daemon alerts to alerts until ctx is done
+func (w *DaemonWatcher) Run(ctx context.Context, out chan<- events.Message, alerts chan<- notifications.Event) {
+	since := time.Time{}
+	var lastNano int64
+
+	for {
+		started := w.now()
+		err := w.stream(ctx, since, out, &lastNano)
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("Event stream interrupted: %v\n", err)
+		w.setErr(err)
+
+		// resume after the last event, or from when the stream was opened when there was none
+		if lastNano > 0 {
+			since = time.Unix(0, lastNano)
+		} else if since.IsZero() {
+			since = started
+		}
+
+		if !w.sleep(ctx, w.minBackoff) || !w.waitForDaemon(ctx, err, alerts) {
+			return
+		}
+		w.setErr(nil)
+		metrics.StreamReconnects.WithLabelValues(w.host).Inc()
+	}
+}
+
+// Err is the reason the event stream is down, nil while it is connected
+func (w *DaemonWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *DaemonWatcher) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+}
+
+func (w *DaemonWatcher) stream(ctx context.Context, since time.Time, out chan<- events.Message, lastNano *int64) error {
+	// stops the reader goroutine of the docker client when the stream is abandoned
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := w.source.StreamEventsSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case msg, ok := <-stream.Events:
+			if !ok {
+				return errStreamClosed
+			}
+			// replayed events of the second the stream was resumed from
+			if msg.TimeNano != 0 && msg.TimeNano <= *lastNano {
+				continue
+			}
+			*lastNano = msg.TimeNano
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case err, ok := <-stream.Errors:
+			// the docker client closes the error channel after the first error
+			if !ok || err == nil {
+				return errStreamClosed
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForDaemon returns once the daemon answers, false when ctx is done first
+func (w *DaemonWatcher) waitForDaemon(ctx context.Context, cause error, alerts chan<- notifications.Event) bool {
+	lost := w.now()
+	lastErr := cause
+	alerted := false
+	backoff := w.minBackoff
+
+	for {
+		info, err := w.source.Info(ctx)
+		if err == nil {
+			if alerted {
+				host := info.Name
+				if host == "" {
+					host = w.host
+				}
+				return w.send(ctx, alerts, notifications.Event{
+					Type:              "daemon",
+					Action:            "reachable",
+					Time:              w.now().Unix(),
+					Name:              host,
+					DaemonVersion:     info.ServerVersion,
+					ContainersRunning: info.ContainersRunning,
+				})
+			}
+			return true
+		}
+		lastErr = err
+		w.setErr(err)
+
+		if !alerted && w.now().Sub(lost) >= w.grace {
+			alerted = true
+			evt := notifications.Event{
+				Type:        "daemon",
+				Action:      "unreachable",
+				Time:        w.now().Unix(),
+				Name:        w.host,
+				DaemonError: lastErr.Error(),
+			}
+			if !w.send(ctx, alerts, evt) {
+				return false
+			}
+		}
+
+		if !w.sleep(ctx, backoff) {
+			return false
+		}
+		backoff = min(backoff*2, w.maxBackoff)
+	}
+}
+
+func (w *DaemonWatcher) send(ctx context.Context, alerts chan<- notifications.Event, evt notifications.Event) bool {
+	select {
+	case alerts <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *DaemonWatcher) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
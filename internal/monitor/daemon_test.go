@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+type fakeDaemon struct {
+	mu      sync.Mutex
+	streams []*docker.EventStream
+	since   []time.Time
+	down    bool
+}
+
+func (f *fakeDaemon) StreamEventsSince(ctx context.Context, since time.Time, filterArgs ...filters.Args) (*docker.EventStream, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.since = append(f.since, since)
+	if len(f.streams) == 0 {
+		return nil, fmt.Errorf("no more streams")
+	}
+	stream := f.streams[0]
+	f.streams = f.streams[1:]
+	return stream, nil
+}
+
+func (f *fakeDaemon) Info(ctx context.Context) (system.Info, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return system.Info{}, fmt.Errorf("Cannot connect to the Docker daemon")
+	}
+	return system.Info{Name: "host-x", ServerVersion: "27.5.1", ContainersRunning: 12}, nil
+}
+
+func (f *fakeDaemon) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func newStream() (*docker.EventStream, chan events.Message, chan error) {
+	msgs := make(chan events.Message)
+	errs := make(chan error, 1)
+	return &docker.EventStream{Events: msgs, Errors: errs}, msgs, errs
+}
+
+func TestDaemonWatcher(t *testing.T) {
+	first, firstMsgs, firstErrs := newStream()
+	second, secondMsgs, _ := newStream()
+	source := &fakeDaemon{streams: []*docker.EventStream{first, second}}
+
+	watcher := NewDaemonWatcher(source, "host-x", 20*time.Millisecond)
+	watcher.minBackoff = time.Millisecond
+	watcher.maxBackoff = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan events.Message)
+	alerts := make(chan notifications.Event)
+	go watcher.Run(ctx, out, alerts)
+
+	firstMsgs <- events.Message{Action: "start", TimeNano: 1700000000000000100}
+	assert.Equal(t, events.Action("start"), (<-out).Action)
+
+	source.setDown(true)
+	firstErrs <- fmt.Errorf("unexpected EOF")
+
+	unreachable := <-alerts
+	assert.ErrorContains(t, watcher.Err(), "Cannot connect")
+	assert.Equal(t, "daemon", unreachable.Type)
+	assert.Equal(t, "unreachable", unreachable.Action)
+	assert.Equal(t, "host-x", unreachable.Name)
+	assert.Contains(t, unreachable.DaemonError, "Cannot connect")
+
+	source.setDown(false)
+	reachable := <-alerts
+	assert.Equal(t, "reachable", reachable.Action)
+	assert.Equal(t, "27.5.1", reachable.DaemonVersion)
+	assert.Equal(t, 12, reachable.ContainersRunning)
+	assert.Equal(t, "Docker daemon back on host-x, version 27.5.1, 12 containers running", reachable.Text())
+
+	// the replayed event is dropped
+	secondMsgs <- events.Message{Action: "start", TimeNano: 1700000000000000100}
+	secondMsgs <- events.Message{Action: "die", TimeNano: 1700000000000000200}
+	assert.Equal(t, events.Action("die"), (<-out).Action)
+	assert.NoError(t, watcher.Err())
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	require.Len(t, source.since, 2)
+	assert.True(t, source.since[0].IsZero())
+	assert.Equal(t, int64(1700000000000000100), source.since[1].UnixNano())
+}
+
+func TestDaemonWatcher_ShortOutage(t *testing.T) {
+	first, _, firstErrs := newStream()
+	second, secondMsgs, _ := newStream()
+	source := &fakeDaemon{streams: []*docker.EventStream{first, second}}
+
+	watcher := NewDaemonWatcher(source, "host-x", time.Minute)
+	watcher.minBackoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan events.Message)
+	alerts := make(chan notifications.Event)
+	go watcher.Run(ctx, out, alerts)
+
+	close(firstErrs)
+	secondMsgs <- events.Message{Action: "start"}
+
+	select {
+	case evt := <-alerts:
+		t.Fatalf("unexpected alert %+v", evt)
+	case msg := <-out:
+		assert.Equal(t, events.Action("start"), msg.Action)
+	}
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+const (
+	MetricDiskFree    = "disk_free"
+	MetricReclaimable = "reclaimable"
+)
+
+// DiskSource is the part of the docker client used by the disk poller
+type DiskSource interface {
+	DiskUsage(ctx context.Context) (docker.DiskUsage, error)
+}
+
+// DiskThresholds turn a check off when set to zero
+type DiskThresholds struct {
+	MinFreePercent float64
+	// MaxReclaimable is the size in bytes of unused images and build cache
+	MaxReclaimable int64
+	// Hysteresis in percentage points of free space
+	Hysteresis float64
+	// TopN largest images and volumes are listed in alerts
+	TopN int
+}
+
+// DiskPoller watches the filesystem of the docker data root and the space
+// taken by images, build cache and volumes
+type DiskPoller struct {
+	source     DiskSource
+	path       string
+	interval   time.Duration
+	thresholds DiskThresholds
+
+	free        thresholdState
+	reclaimable thresholdState
+	statfs      func(path string) (total, free uint64, err error)
+	now         func() time.Time
+}
+
+func NewDiskPoller(source DiskSource, path string, interval time.Duration, thresholds DiskThresholds) *DiskPoller {
+	return &DiskPoller{
+		source:     source,
+		path:       path,
+		interval:   interval,
+		thresholds: thresholds,
+		statfs:     statfs,
+		now:        time.Now,
+	}
+}
+
+// Run polls until ctx is done, events are sent to out
+func (p *DiskPoller) Run(ctx context.Context, out chan<- notifications.Event) {
+	run(ctx, p.interval, p.poll, out)
+}
+
+func (p *DiskPoller) poll(ctx context.Context) []notifications.Event {
+	now := p.now()
+	report := &notifications.DiskReport{Path: p.path}
+	var events []notifications.Event
+
+	// both checks are independent, one failing does not skip the other
+	usage, usageErr := p.source.DiskUsage(ctx)
+	if usageErr != nil {
+		fmt.Printf("Disk poller: %v\n", usageErr)
+	} else {
+		report.ImagesReclaimable = usage.ImagesReclaimable
+		report.BuildCacheReclaimable = usage.BuildCacheReclaimable
+		report.TopImages = topItems(usage.Images, p.thresholds.TopN)
+		report.TopVolumes = topItems(usage.Volumes, p.thresholds.TopN)
+	}
+
+	total, free, statErr := p.statfs(p.path)
+	if statErr != nil {
+		fmt.Printf("Disk poller: %v\n", statErr)
+	} else {
+		report.Total = int64(total)
+		report.Free = int64(free)
+	}
+
+	if minFree := p.thresholds.MinFreePercent; minFree > 0 && statErr == nil && total > 0 {
+		freePercent := float64(free) / float64(total) * 100
+		// tracked as used space so a single state machine handles both directions
+		used, maxUsed := 100-freePercent, 100-minFree
+		if action := p.free.update(now, used, maxUsed, maxUsed-p.thresholds.Hysteresis, 0); action != "" {
+			events = append(events, p.newEvent(action, MetricDiskFree, freePercent, minFree, report, now))
+		}
+	}
+
+	if maxReclaimable := p.thresholds.MaxReclaimable; maxReclaimable > 0 && usageErr == nil {
+		// only changes with pulls, builds and prunes, it does not flap
+		reclaimable := float64(usage.ImagesReclaimable + usage.BuildCacheReclaimable)
+		if action := p.reclaimable.update(now, reclaimable, float64(maxReclaimable), float64(maxReclaimable), 0); action != "" {
+			events = append(events, p.newEvent(action, MetricReclaimable, reclaimable, float64(maxReclaimable), report, now))
+		}
+	}
+
+	return events
+}
+
+func (p *DiskPoller) newEvent(action, metric string, value, threshold float64, report *notifications.DiskReport, now time.Time) notifications.Event {
+	return notifications.Event{
+		Type:      "disk",
+		Action:    action,
+		Time:      now.Unix(),
+		Name:      p.path,
+		Metric:    metric,
+		Value:     value,
+		Threshold: threshold,
+		Disk:      report,
+	}
+}
+
+func topItems(items []docker.DiskItem, n int) []notifications.DiskItem {
+	if len(items) > n {
+		items = items[:n]
+	}
+	var result []notifications.DiskItem
+	for _, item := range items {
+		result = append(result, notifications.DiskItem{Name: item.Name, Size: item.Size})
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+)
+
+type fakeDisk struct {
+	usage docker.DiskUsage
+}
+
+func (f *fakeDisk) DiskUsage(ctx context.Context) (docker.DiskUsage, error) {
+	return f.usage, nil
+}
+
+func TestDiskPoller(t *testing.T) {
+	source := &fakeDisk{usage: docker.DiskUsage{
+		ImagesReclaimable:     8 << 30,
+		BuildCacheReclaimable: 1 << 30,
+		Images:                []docker.DiskItem{{Name: "postgres:16", Size: 3 << 30}, {Name: "node:20", Size: 1 << 30}, {Name: "redis:7", Size: 100 << 20}},
+		Volumes:               []docker.DiskItem{{Name: "pgdata", Size: 20 << 30}},
+	}}
+	poller := NewDiskPoller(source, "/var/lib/docker", time.Minute, DiskThresholds{
+		MinFreePercent: 10,
+		MaxReclaimable: 10 << 30,
+		Hysteresis:     5,
+		TopN:           2,
+	})
+	free := uint64(50)
+	poller.statfs = func(path string) (uint64, uint64, error) {
+		assert.Equal(t, "/var/lib/docker", path)
+		return 1000, free, nil
+	}
+	ctx := context.Background()
+
+	events := poller.poll(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, "disk", events[0].Type)
+	assert.Equal(t, "threshold_exceeded", events[0].Action)
+	assert.Equal(t, MetricDiskFree, events[0].Metric)
+	assert.InDelta(t, 5.0, events[0].Value, 0.001)
+	assert.Equal(t, 10.0, events[0].Threshold)
+
+	report := events[0].Disk
+	require.NotNil(t, report)
+	assert.Equal(t, int64(1000), report.Total)
+	assert.Equal(t, int64(50), report.Free)
+	assert.Equal(t, int64(8<<30), report.ImagesReclaimable)
+	assert.Len(t, report.TopImages, 2)
+	assert.Equal(t, "postgres:16", report.TopImages[0].Name)
+	assert.Len(t, report.TopVolumes, 1)
+
+	// still within the hysteresis band
+	free = 120
+	assert.Empty(t, poller.poll(ctx))
+
+	free = 160
+	source.usage.ImagesReclaimable = 10 << 30
+	events = poller.poll(ctx)
+	require.Len(t, events, 2)
+	assert.Equal(t, "threshold_resolved", events[0].Action)
+	assert.Equal(t, MetricReclaimable, events[1].Metric)
+	assert.Equal(t, float64(11<<30), events[1].Value)
+
+	source.usage.ImagesReclaimable = 0
+	events = poller.poll(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, "threshold_resolved", events[0].Action)
+	assert.Equal(t, MetricReclaimable, events[0].Metric)
+}
@@ -0,0 +1,29 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// run calls poll every interval until ctx is done
+func run(ctx context.Context, interval time.Duration, poll func(context.Context) []notifications.Event, out chan<- notifications.Event) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, evt := range poll(ctx) {
+				select {
+				case out <- evt:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// ReportSource is the part of the docker client used for the server report
+type ReportSource interface {
+	Info(ctx context.Context) (system.Info, error)
+	Containers(ctx context.Context) ([]types.Container, error)
+	DiskUsage(ctx context.Context) (docker.DiskUsage, error)
+}
+
+// NewServerInfo takes the host details of docker info
+func NewServerInfo(info system.Info) *notifications.ServerInfo {
+	return &notifications.ServerInfo{
+		Version:           info.ServerVersion,
+		Host:              info.Name,
+		OSType:            info.OSType,
+		OperatingSystem:   info.OperatingSystem,
+		KernelVersion:     info.KernelVersion,
+		Architecture:      info.Architecture,
+		CPUs:              info.NCPU,
+		MemoryMB:          info.MemTotal / 1024 / 1024,
+		ContainersRunning: info.ContainersRunning,
+		ContainersPaused:  info.ContainersPaused,
+		ContainersStopped: info.ContainersStopped,
+		Images:            info.Images,
+	}
+}
+
+// ServerReport adds disk usage and the failing containers to the host details,
+// the parts that fail are logged and left out
+func ServerReport(ctx context.Context, source ReportSource) (*notifications.ServerInfo, error) {
+	info, err := source.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker info: %w", err)
+	}
+	report := NewServerInfo(info)
+
+	if usage, err := source.DiskUsage(ctx); err != nil {
+		fmt.Printf("Failed to get disk usage for the report: %v\n", err)
+	} else {
+		report.DiskUsed = usage.ImagesSize + usage.VolumesSize + usage.BuildCacheSize
+		report.DiskReclaimable = usage.ImagesReclaimable + usage.BuildCacheReclaimable
+	}
+
+	containers, err := source.Containers(ctx)
+	if err != nil {
+		fmt.Printf("Failed to list containers for the report: %v\n", err)
+		return report, nil
+	}
+	for _, c := range containers {
+		switch {
+		case c.State == "restarting" || strings.Contains(c.Status, "(unhealthy)"):
+			report.Unhealthy = append(report.Unhealthy, containerName(c))
+		case c.State == "dead":
+			report.Failed = append(report.Failed, containerName(c))
+		case c.State == "exited":
+			if code, ok := exitCode(c.Status); !ok || code != 0 {
+				report.Failed = append(report.Failed, containerName(c))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// HostUptime reads the uptime of the local machine, containers see the one of the host
+func HostUptime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uptime: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("failed to read uptime: empty /proc/uptime")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uptime: %w", err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+)
+
+type fakeReport struct {
+	fakeContainers
+	diskErr error
+}
+
+func (f fakeReport) Info(ctx context.Context) (system.Info, error) {
+	return system.Info{
+		Name:              "host-x",
+		ServerVersion:     "27.5.1",
+		OperatingSystem:   "Ubuntu 24.04 LTS",
+		KernelVersion:     "6.8.0-45-generic",
+		NCPU:              8,
+		MemTotal:          16 << 30,
+		ContainersRunning: 3,
+		ContainersStopped: 2,
+		Images:            7,
+	}, nil
+}
+
+func (f fakeReport) DiskUsage(ctx context.Context) (docker.DiskUsage, error) {
+	return docker.DiskUsage{ImagesSize: 3 << 30, VolumesSize: 1 << 30, ImagesReclaimable: 1 << 30}, f.diskErr
+}
+
+func TestServerReport(t *testing.T) {
+	source := fakeReport{fakeContainers: fakeContainers{
+		{ID: "a", Names: []string{"/api"}, State: "running", Status: "Up 2 hours (healthy)"},
+		{ID: "b", Names: []string{"/db"}, State: "running", Status: "Up 5 minutes (unhealthy)"},
+		{ID: "c", Names: []string{"/worker"}, State: "exited", Status: "Exited (1) 3 hours ago"},
+		{ID: "d", Names: []string{"/migrate"}, State: "exited", Status: "Exited (0) 1 day ago"},
+		{ID: "e", Names: []string{"/cron"}, State: "restarting", Status: "Restarting (1) 10 seconds ago"},
+	}}
+
+	report, err := ServerReport(context.Background(), source)
+	require.NoError(t, err)
+	assert.Equal(t, "27.5.1", report.Version)
+	assert.Equal(t, "6.8.0-45-generic", report.KernelVersion)
+	assert.Equal(t, int64(16384), report.MemoryMB)
+	assert.Equal(t, 3, report.ContainersRunning)
+	assert.Equal(t, 7, report.Images)
+	assert.Equal(t, int64(4<<30), report.DiskUsed)
+	assert.Equal(t, int64(1<<30), report.DiskReclaimable)
+	assert.Equal(t, []string{"db", "cron"}, report.Unhealthy)
+	assert.Equal(t, []string{"worker"}, report.Failed)
+
+	t.Run("disk usage is optional", func(t *testing.T) {
+		source.diskErr = fmt.Errorf("timeout")
+		report, err := ServerReport(context.Background(), source)
+		require.NoError(t, err)
+		assert.Zero(t, report.DiskUsed)
+		assert.Len(t, report.Failed, 1)
+	})
+}
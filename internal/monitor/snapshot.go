@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// SnapshotSource is the part of the docker client used for the startup snapshot
+type SnapshotSource interface {
+	Containers(ctx context.Context) ([]types.Container, error)
+}
+
+// Snapshot reports the containers that are restarting, unhealthy or exited with a non-zero code,
+// so that problems which started while docker-alerts was not running are not missed
+func Snapshot(ctx context.Context, source SnapshotSource) ([]notifications.Event, error) {
+	containers, err := source.Containers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take container snapshot: %w", err)
+	}
+
+	now := time.Now().Unix()
+	var result []notifications.Event
+	for _, c := range containers {
+		attributes := map[string]string{
+			"name":  containerName(c),
+			"image": c.Image,
+		}
+		for key, value := range c.Labels {
+			attributes[key] = value
+		}
+
+		var action events.Action
+		switch {
+		case c.State == "restarting":
+			action = "restarting"
+		case strings.Contains(c.Status, "(unhealthy)"):
+			action = events.ActionHealthStatusUnhealthy
+		case c.State == "exited" || c.State == "dead":
+			code, ok := exitCode(c.Status)
+			if ok && code == 0 {
+				continue
+			}
+			action = events.ActionDie
+			if ok {
+				attributes["exitCode"] = strconv.Itoa(code)
+			}
+		default:
+			continue
+		}
+
+		// the same fields as for the live events of these containers
+		evt := notifications.NewEventFromDocker(events.Message{
+			Type:   events.ContainerEventType,
+			Action: action,
+			Actor:  events.Actor{ID: c.ID, Attributes: attributes},
+			Time:   now,
+		})
+		evt.Snapshot = true
+		result = append(result, evt)
+	}
+
+	return result, nil
+}
+
+// exitCode parses the status of exited containers, e.g. "Exited (137) 5 minutes ago"
+func exitCode(status string) (int, bool) {
+	var code int
+	if _, err := fmt.Sscanf(status, "Exited (%d)", &code); err != nil {
+		return 0, false
+	}
+	return code, true
+}
@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeContainers []types.Container
+
+func (f fakeContainers) Containers(ctx context.Context) ([]types.Container, error) {
+	return f, nil
+}
+
+func TestSnapshot(t *testing.T) {
+	source := fakeContainers{
+		{ID: "a", Names: []string{"/api"}, Image: "api:1", State: "running", Status: "Up 2 hours (healthy)"},
+		{ID: "b", Names: []string{"/db"}, Image: "postgres:16", State: "running", Status: "Up 5 minutes (unhealthy)",
+			Labels: map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "db"}},
+		{ID: "c", Names: []string{"/worker"}, Image: "worker:1", State: "exited", Status: "Exited (137) 3 hours ago"},
+		{ID: "d", Names: []string{"/migrate"}, Image: "migrate:1", State: "exited", Status: "Exited (0) 1 day ago"},
+		{ID: "e", Names: []string{"/cron"}, Image: "cron:1", State: "restarting", Status: "Restarting (1) 10 seconds ago"},
+		{ID: "f", Names: []string{"/new"}, Image: "new:1", State: "created", Status: "Created"},
+	}
+
+	events, err := Snapshot(context.Background(), source)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	unhealthy := events[0]
+	assert.Equal(t, "health_status: unhealthy", unhealthy.Action)
+	assert.Equal(t, "db", unhealthy.Name)
+	assert.Equal(t, "shop", unhealthy.Project)
+	assert.Equal(t, "db", unhealthy.Service)
+	assert.True(t, unhealthy.Snapshot)
+
+	exited := events[1]
+	assert.Equal(t, "die", exited.Action)
+	assert.Equal(t, "137", exited.ExitCode)
+	assert.Equal(t, "c", exited.Container)
+	assert.Equal(t, `container stop worker (worker:1) (at startup) Exit code: 137 "Immediate termination SIGKILL"`, exited.Text())
+
+	assert.Equal(t, "restarting", events[2].Action)
+	assert.Equal(t, "cron", events[2].Name)
+}
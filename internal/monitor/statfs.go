@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package monitor
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// statfs returns the size and the space available to unprivileged users of the filesystem at path
+func statfs(path string) (total, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, fmt.Errorf("failed to stat filesystem %s: %w", path, err)
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), uint64(st.Bavail) * uint64(st.Bsize), nil
+}
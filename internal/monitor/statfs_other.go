@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package monitor
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func statfs(path string) (total, free uint64, err error) {
+	return 0, 0, fmt.Errorf("filesystem stats are not supported on %s", runtime.GOOS)
+}
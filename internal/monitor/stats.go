@@ -0,0 +1,266 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+const (
+	MetricCPU      = "cpu"
+	MetricMemory   = "memory"
+	MetricRestarts = "restarts"
+)
+
+// stats calls block for about a second while docker takes two cpu samples
+const statsConcurrency = 8
+
+// StatsSource is the part of the docker client used by the poller
+type StatsSource interface {
+	RunningContainers(ctx context.Context) ([]types.Container, error)
+	Usage(ctx context.Context, containerID string) (docker.Usage, error)
+	Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+}
+
+// Thresholds turn a metric off when set to zero
+type Thresholds struct {
+	CPUPercent    float64
+	MemoryPercent float64
+	// Restarts within Duration
+	Restarts int
+	// Duration a value has to stay over its threshold before an alert
+	Duration time.Duration
+	// Hysteresis in percentage points, an alert is resolved once the value
+	// drops below threshold - hysteresis so it does not flap around the threshold
+	Hysteresis float64
+}
+
+type thresholdState struct {
+	overSince time.Time
+	alerting  bool
+}
+
+type restartSample struct {
+	at    time.Time
+	count int
+}
+
+// StatsPoller periodically samples running containers and emits
+// threshold_exceeded and threshold_resolved events
+type StatsPoller struct {
+	source     StatsSource
+	interval   time.Duration
+	thresholds Thresholds
+
+	states   map[string]*thresholdState
+	restarts map[string][]restartSample
+	now      func() time.Time
+}
+
+func NewStatsPoller(source StatsSource, interval time.Duration, thresholds Thresholds) *StatsPoller {
+	return &StatsPoller{
+		source:     source,
+		interval:   interval,
+		thresholds: thresholds,
+		states:     map[string]*thresholdState{},
+		restarts:   map[string][]restartSample{},
+		now:        time.Now,
+	}
+}
+
+// Run polls until ctx is done, events are sent to out
+func (p *StatsPoller) Run(ctx context.Context, out chan<- notifications.Event) {
+	run(ctx, p.interval, p.poll, out)
+}
+
+type sample struct {
+	container types.Container
+	usage     docker.Usage
+	restarts  int
+	err       error
+}
+
+func (p *StatsPoller) poll(ctx context.Context) []notifications.Event {
+	containers, err := p.source.RunningContainers(ctx)
+	if err != nil {
+		fmt.Printf("Stats poller: %v\n", err)
+		return nil
+	}
+
+	samples := make([]sample, len(containers))
+	slots := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+	for i, c := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			samples[i] = p.sample(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	var events []notifications.Event
+	seen := map[string]bool{}
+	for _, s := range samples {
+		if s.err != nil {
+			fmt.Printf("Stats poller: %s: %v\n", containerName(s.container), s.err)
+			continue
+		}
+		seen[s.container.ID] = true
+		events = append(events, p.evaluate(s)...)
+	}
+
+	p.forget(seen)
+
+	return events
+}
+
+func (p *StatsPoller) sample(ctx context.Context, c types.Container) sample {
+	s := sample{container: c}
+
+	if c.State == "running" && (p.thresholds.CPUPercent > 0 || p.thresholds.MemoryPercent > 0) {
+		s.usage, s.err = p.source.Usage(ctx, c.ID)
+		if s.err != nil {
+			return s
+		}
+	}
+
+	if p.thresholds.Restarts > 0 {
+		inspect, err := p.source.Inspect(ctx, c.ID)
+		if err != nil {
+			s.err = err
+			return s
+		}
+		if inspect.ContainerJSONBase != nil {
+			s.restarts = inspect.RestartCount
+		}
+	}
+
+	return s
+}
+
+func (p *StatsPoller) evaluate(s sample) []notifications.Event {
+	now := p.now()
+	var events []notifications.Event
+
+	check := func(metric string, value, threshold, resolveBelow float64, sustain time.Duration) {
+		if threshold <= 0 {
+			return
+		}
+		key := s.container.ID + "/" + metric
+		state, ok := p.states[key]
+		if !ok {
+			state = &thresholdState{}
+			p.states[key] = state
+		}
+
+		action := state.update(now, value, threshold, resolveBelow, sustain)
+		if action != "" {
+			events = append(events, newThresholdEvent(s.container, action, metric, value, threshold, now))
+		}
+	}
+
+	if s.container.State == "running" {
+		check(MetricCPU, s.usage.CPUPercent, p.thresholds.CPUPercent, p.thresholds.CPUPercent-p.thresholds.Hysteresis, p.thresholds.Duration)
+		check(MetricMemory, s.usage.MemoryPercent, p.thresholds.MemoryPercent, p.thresholds.MemoryPercent-p.thresholds.Hysteresis, p.thresholds.Duration)
+	}
+
+	if p.thresholds.Restarts > 0 {
+		// restarts are counted over the window already, no need to sustain them
+		restarts := p.recordRestarts(s.container.ID, s.restarts, now)
+		check(MetricRestarts, float64(restarts), float64(p.thresholds.Restarts), 1, 0)
+	}
+
+	return events
+}
+
+// update returns the action to notify, if any
+func (s *thresholdState) update(now time.Time, value, threshold, resolveBelow float64, sustain time.Duration) string {
+	if s.alerting {
+		if value < resolveBelow {
+			s.alerting = false
+			s.overSince = time.Time{}
+			return "threshold_resolved"
+		}
+		return ""
+	}
+
+	if value < threshold {
+		s.overSince = time.Time{}
+		return ""
+	}
+
+	if s.overSince.IsZero() {
+		s.overSince = now
+	}
+	if now.Sub(s.overSince) >= sustain {
+		s.alerting = true
+		return "threshold_exceeded"
+	}
+	return ""
+}
+
+// recordRestarts returns the number of restarts within the threshold duration
+func (p *StatsPoller) recordRestarts(containerID string, count int, now time.Time) int {
+	window := p.thresholds.Duration
+	if window < p.interval {
+		window = p.interval
+	}
+
+	history := append(p.restarts[containerID], restartSample{at: now, count: count})
+	// keep the last sample before the window as the baseline
+	for len(history) > 1 && now.Sub(history[1].at) >= window {
+		history = history[1:]
+	}
+	p.restarts[containerID] = history
+
+	return count - history[0].count
+}
+
+// forget drops the state of containers that are gone
+func (p *StatsPoller) forget(seen map[string]bool) {
+	for key := range p.states {
+		id, _, _ := strings.Cut(key, "/")
+		if !seen[id] {
+			delete(p.states, key)
+		}
+	}
+	for id := range p.restarts {
+		if !seen[id] {
+			delete(p.restarts, id)
+		}
+	}
+}
+
+func newThresholdEvent(c types.Container, action, metric string, value, threshold float64, now time.Time) notifications.Event {
+	return notifications.Event{
+		Type:      "container",
+		Action:    action,
+		Container: c.ID,
+		Image:     c.Image,
+		Time:      now.Unix(),
+		Labels:    c.Labels,
+		Name:      containerName(c),
+		Project:   c.Labels["com.docker.compose.project"],
+		Service:   c.Labels["com.docker.compose.service"],
+		Metric:    metric,
+		Value:     value,
+		Threshold: threshold,
+	}
+}
+
+func containerName(c types.Container) string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return c.ID
+}
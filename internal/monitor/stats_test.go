@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+type fakeStats struct {
+	mu         sync.Mutex
+	containers []types.Container
+	usage      map[string]docker.Usage
+	restarts   map[string]int
+}
+
+func (f *fakeStats) RunningContainers(ctx context.Context) ([]types.Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.containers, nil
+}
+
+func (f *fakeStats) Usage(ctx context.Context, containerID string) (docker.Usage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.usage[containerID], nil
+}
+
+func (f *fakeStats) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: containerID, RestartCount: f.restarts[containerID]},
+	}, nil
+}
+
+func newTestPoller(source StatsSource, thresholds Thresholds) (*StatsPoller, *time.Time) {
+	poller := NewStatsPoller(source, 10*time.Second, thresholds)
+	now := time.Unix(1700000000, 0)
+	poller.now = func() time.Time { return now }
+	return poller, &now
+}
+
+func actions(events []notifications.Event) []string {
+	var result []string
+	for _, e := range events {
+		result = append(result, e.Action+" "+e.Metric)
+	}
+	return result
+}
+
+func TestStatsPoller_Sustained(t *testing.T) {
+	source := &fakeStats{
+		containers: []types.Container{{
+			ID:     "abc",
+			Names:  []string{"/api"},
+			Image:  "api:1",
+			State:  "running",
+			Labels: map[string]string{"com.docker.compose.project": "shop"},
+		}},
+		usage: map[string]docker.Usage{"abc": {CPUPercent: 95}},
+	}
+	poller, now := newTestPoller(source, Thresholds{CPUPercent: 90, Duration: 30 * time.Second, Hysteresis: 10})
+	ctx := context.Background()
+
+	assert.Empty(t, poller.poll(ctx))
+
+	*now = now.Add(20 * time.Second)
+	assert.Empty(t, poller.poll(ctx))
+
+	*now = now.Add(10 * time.Second)
+	events := poller.poll(ctx)
+	require.Equal(t, []string{"threshold_exceeded cpu"}, actions(events))
+	assert.Equal(t, "api", events[0].Name)
+	assert.Equal(t, "shop", events[0].Project)
+	assert.Equal(t, 95.0, events[0].Value)
+	assert.Equal(t, 90.0, events[0].Threshold)
+
+	// no repeats while alerting
+	*now = now.Add(10 * time.Second)
+	assert.Empty(t, poller.poll(ctx))
+
+	// within the hysteresis band
+	source.usage["abc"] = docker.Usage{CPUPercent: 85}
+	assert.Empty(t, poller.poll(ctx))
+
+	source.usage["abc"] = docker.Usage{CPUPercent: 70}
+	assert.Equal(t, []string{"threshold_resolved cpu"}, actions(poller.poll(ctx)))
+
+	t.Run("short spikes do not alert", func(t *testing.T) {
+		source.usage["abc"] = docker.Usage{CPUPercent: 99}
+		assert.Empty(t, poller.poll(ctx))
+		*now = now.Add(20 * time.Second)
+		source.usage["abc"] = docker.Usage{CPUPercent: 50}
+		assert.Empty(t, poller.poll(ctx))
+		*now = now.Add(20 * time.Second)
+		source.usage["abc"] = docker.Usage{CPUPercent: 99}
+		assert.Empty(t, poller.poll(ctx))
+	})
+}
+
+func TestStatsPoller_Memory(t *testing.T) {
+	source := &fakeStats{
+		containers: []types.Container{{ID: "abc", Names: []string{"/db"}, State: "running"}},
+		usage:      map[string]docker.Usage{"abc": {MemoryPercent: 92}},
+	}
+	poller, _ := newTestPoller(source, Thresholds{MemoryPercent: 90, Hysteresis: 5})
+
+	assert.Equal(t, []string{"threshold_exceeded memory"}, actions(poller.poll(context.Background())))
+
+	// containers that are gone are forgotten
+	source.containers = nil
+	assert.Empty(t, poller.poll(context.Background()))
+	assert.Empty(t, poller.states)
+}
+
+func TestStatsPoller_Restarts(t *testing.T) {
+	source := &fakeStats{
+		containers: []types.Container{{ID: "abc", Names: []string{"/worker"}, State: "restarting"}},
+		restarts:   map[string]int{"abc": 5},
+	}
+	poller, now := newTestPoller(source, Thresholds{Restarts: 3, Duration: time.Minute})
+	ctx := context.Background()
+
+	// the first sample is the baseline
+	assert.Empty(t, poller.poll(ctx))
+
+	*now = now.Add(20 * time.Second)
+	source.restarts["abc"] = 7
+	assert.Empty(t, poller.poll(ctx))
+
+	*now = now.Add(20 * time.Second)
+	source.restarts["abc"] = 8
+	events := poller.poll(ctx)
+	require.Equal(t, []string{"threshold_exceeded restarts"}, actions(events))
+	assert.Equal(t, 3.0, events[0].Value)
+
+	// stable for a whole window
+	*now = now.Add(40 * time.Second)
+	assert.Empty(t, poller.poll(ctx))
+	*now = now.Add(40 * time.Second)
+	assert.Equal(t, []string{"threshold_resolved restarts"}, actions(poller.poll(ctx)))
+}
+
+func TestStatsPoller_Run(t *testing.T) {
+	source := &fakeStats{
+		containers: []types.Container{{ID: "abc", Names: []string{"/api"}, State: "running"}},
+		usage:      map[string]docker.Usage{"abc": {CPUPercent: 100}},
+	}
+	poller := NewStatsPoller(source, 10*time.Millisecond, Thresholds{CPUPercent: 90})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan notifications.Event)
+	go poller.Run(ctx, out)
+
+	select {
+	case evt := <-out:
+		assert.Equal(t, "threshold_exceeded", evt.Action)
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+
+	"github.com/lotas/docker-alerts/internal/notifications"
+)
+
+// TaskSource is the part of the docker client used by the task watcher
+type TaskSource interface {
+	Tasks(ctx context.Context) ([]swarm.Task, error)
+	ServiceNames(ctx context.Context) (map[string]string, error)
+	NodeNames(ctx context.Context) (map[string]string, error)
+}
+
+// TaskWatcher polls the tasks of a swarm and reports the ones that failed or were rejected.
+// The event stream of a manager only covers containers on its own node, tasks
+// rescheduled away from a failed node are only visible through the task list.
+type TaskWatcher struct {
+	source   TaskSource
+	interval time.Duration
+
+	states  map[string]swarm.TaskState
+	seeded  bool
+	nodes   map[string]string
+	nodesAt time.Time
+	now     func() time.Time
+}
+
+// node names rarely change, no need to list nodes on every poll
+const nodeNamesTTL = 10 * time.Minute
+
+func NewTaskWatcher(source TaskSource, interval time.Duration) *TaskWatcher {
+	return &TaskWatcher{
+		source:   source,
+		interval: interval,
+		states:   map[string]swarm.TaskState{},
+		now:      time.Now,
+	}
+}
+
+// Run polls until ctx is done, events are sent to out
+func (w *TaskWatcher) Run(ctx context.Context, out chan<- notifications.Event) {
+	run(ctx, w.interval, w.poll, out)
+}
+
+func (w *TaskWatcher) poll(ctx context.Context) []notifications.Event {
+	tasks, err := w.source.Tasks(ctx)
+	if err != nil {
+		fmt.Printf("Task watcher: %v\n", err)
+		return nil
+	}
+
+	var failed []swarm.Task
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.ID] = true
+		previous, known := w.states[task.ID]
+		w.states[task.ID] = task.Status.State
+
+		if !isFailedTask(task.Status.State) || (known && previous == task.Status.State) {
+			continue
+		}
+		// tasks that already failed before we started are history
+		if w.seeded {
+			failed = append(failed, task)
+		}
+	}
+	w.seeded = true
+
+	for id := range w.states {
+		if !seen[id] {
+			delete(w.states, id)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	services, err := w.source.ServiceNames(ctx)
+	if err != nil {
+		fmt.Printf("Task watcher: %v\n", err)
+	}
+	nodes := w.nodeNames(ctx)
+
+	events := make([]notifications.Event, 0, len(failed))
+	for _, task := range failed {
+		events = append(events, newTaskEvent(task, services, nodes))
+	}
+	return events
+}
+
+func (w *TaskWatcher) nodeNames(ctx context.Context) map[string]string {
+	if w.nodes != nil && w.now().Sub(w.nodesAt) < nodeNamesTTL {
+		return w.nodes
+	}
+
+	nodes, err := w.source.NodeNames(ctx)
+	if err != nil {
+		fmt.Printf("Task watcher: %v\n", err)
+		return w.nodes
+	}
+	w.nodes = nodes
+	w.nodesAt = w.now()
+	return nodes
+}
+
+func isFailedTask(state swarm.TaskState) bool {
+	return state == swarm.TaskStateFailed || state == swarm.TaskStateRejected
+}
+
+func newTaskEvent(task swarm.Task, services, nodes map[string]string) notifications.Event {
+	service := services[task.ServiceID]
+	if service == "" {
+		service = task.ServiceID
+	}
+	node := nodes[task.NodeID]
+	if node == "" {
+		node = task.NodeID
+	}
+
+	evt := notifications.Event{
+		Type:      "task",
+		Action:    string(task.Status.State),
+		Time:      task.Status.Timestamp.Unix(),
+		Name:      service,
+		Service:   service,
+		Node:      node,
+		TaskSlot:  task.Slot,
+		TaskError: task.Status.Err,
+	}
+	if task.Spec.ContainerSpec != nil {
+		evt.Image = task.Spec.ContainerSpec.Image
+		evt.Project = task.Spec.ContainerSpec.Labels["com.docker.stack.namespace"]
+	}
+	if status := task.Status.ContainerStatus; status != nil {
+		evt.Container = status.ContainerID
+		if status.ExitCode != 0 {
+			evt.ExitCode = fmt.Sprint(status.ExitCode)
+		}
+	}
+
+	return evt
+}
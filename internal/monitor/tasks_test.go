@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTasks struct {
+	tasks     []swarm.Task
+	nodeCalls int
+}
+
+func (f *fakeTasks) Tasks(ctx context.Context) ([]swarm.Task, error) {
+	return f.tasks, nil
+}
+
+func (f *fakeTasks) ServiceNames(ctx context.Context) (map[string]string, error) {
+	return map[string]string{"s1": "shop_api"}, nil
+}
+
+func (f *fakeTasks) NodeNames(ctx context.Context) (map[string]string, error) {
+	f.nodeCalls++
+	return map[string]string{"n1": "worker-1"}, nil
+}
+
+func task(id string, state swarm.TaskState) swarm.Task {
+	return swarm.Task{
+		ID:        id,
+		ServiceID: "s1",
+		NodeID:    "n1",
+		Slot:      2,
+		Spec: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{
+			Image:  "shop/api:1.4",
+			Labels: map[string]string{"com.docker.stack.namespace": "shop"},
+		}},
+		Status: swarm.TaskStatus{State: state, Timestamp: time.Unix(1700000000, 0)},
+	}
+}
+
+func TestTaskWatcher(t *testing.T) {
+	source := &fakeTasks{tasks: []swarm.Task{task("old", swarm.TaskStateFailed), task("t1", swarm.TaskStateRunning)}}
+	watcher := NewTaskWatcher(source, time.Minute)
+	ctx := context.Background()
+
+	// failures from before the start are not reported
+	assert.Empty(t, watcher.poll(ctx))
+
+	failed := task("t1", swarm.TaskStateFailed)
+	failed.Status.Err = "task: non-zero exit (1)"
+	failed.Status.ContainerStatus = &swarm.ContainerStatus{ContainerID: "abc", ExitCode: 1}
+	source.tasks = []swarm.Task{task("old", swarm.TaskStateFailed), failed, task("t2", swarm.TaskStateRejected)}
+
+	events := watcher.poll(ctx)
+	require.Len(t, events, 2)
+	assert.Equal(t, "task", events[0].Type)
+	assert.Equal(t, "failed", events[0].Action)
+	assert.Equal(t, "shop_api", events[0].Name)
+	assert.Equal(t, "shop", events[0].Project)
+	assert.Equal(t, "worker-1", events[0].Node)
+	assert.Equal(t, 2, events[0].TaskSlot)
+	assert.Equal(t, "1", events[0].ExitCode)
+	assert.Equal(t, "abc", events[0].Container)
+	assert.Equal(t, "task: non-zero exit (1)", events[0].TaskError)
+	assert.Equal(t, "rejected", events[1].Action)
+
+	assert.Equal(t, "task failed shop_api.2 (node worker-1) Exit code: 1 \"task: non-zero exit (1)\"", events[0].Text())
+
+	// reported once
+	assert.Empty(t, watcher.poll(ctx))
+	assert.Equal(t, 1, source.nodeCalls)
+
+	source.tasks = nil
+	assert.Empty(t, watcher.poll(ctx))
+	assert.Empty(t, watcher.states)
+}
@@ -0,0 +1,144 @@
+package notifications
+
+// Batch templates render all events of a debounce flush at once.
+// Compose projects with several events are summarized in one line,
+// e.g. "project shop: 12 started, 2 stopped (api exit 137)", followed by the
+// failures and events with logs, which keep their per-event rendering.
+// Everything else is rendered with the per-event template.
+
+const batchTextTpl = `{{range $i, $g := .GroupBy "project"}}{{if $i}}
+{{end}}{{if and $g.Key (gt (len $g.Events) 1)}}{{with $g.Host}}[{{.}}] {{end}}{{T "template.project"}} {{$g.Key}}: {{range $j, $c := $g.Summary}}{{if $j}}, {{end}}{{$c.Count}} {{PastTense $c.Action}}{{end}}
+{{- with $g.Failures}} ({{range $k, $f := .}}{{if $k}}, {{end}}{{or $f.Service $f.Name}} {{T "template.exit"}} {{$f.ExitCode}}{{end}}){{end}}
+{{- range $g.Details}}
+{{.Rendered}}{{end}}
+{{- else}}{{range $j, $e := $g.Events}}{{if $j}}
+{{end}}{{$e.Rendered}}{{end}}{{end}}{{end}}`
+
+const batchMdTpl = `{{range $i, $g := .GroupBy "project"}}{{if $i}}
+{{end}}{{if and $g.Key (gt (len $g.Events) 1)}}{{with $g.Host}}*[{{EscapeMarkdown .}}]* {{end}}{{T "template.project"}} {{WrapCode $g.Key}}: {{range $j, $c := $g.Summary}}{{if $j}}, {{end}}{{$c.Count}} *{{PastTense $c.Action}}*{{end}}
+{{- with $g.Failures}} ({{range $k, $f := .}}{{if $k}}, {{end}}{{WrapCode (or $f.Service $f.Name)}} {{T "template.exit"}} {{WrapCode $f.ExitCode}}{{end}}){{end}}
+{{- range $g.Details}}
+{{.Rendered}}{{end}}
+{{- else}}{{range $j, $e := $g.Events}}{{if $j}}
+{{end}}{{$e.Rendered}}{{end}}{{end}}{{end}}`
+
+const batchHTMLTpl = `{{range $i, $g := .GroupBy "project"}}{{if $i}}
+{{end}}{{if and $g.Key (gt (len $g.Events) 1)}}{{with $g.Host}}<b>[{{EscapeHTML .}}]</b> {{end}}{{T "template.project"}} <code>{{EscapeHTML $g.Key}}</code>: {{range $j, $c := $g.Summary}}{{if $j}}, {{end}}{{$c.Count}} <b>{{PastTense $c.Action}}</b>{{end}}
+{{- with $g.Failures}} ({{range $k, $f := .}}{{if $k}}, {{end}}<code>{{EscapeHTML (or $f.Service $f.Name)}}</code> {{T "template.exit"}} <code>{{$f.ExitCode}}</code>{{end}}){{end}}
+{{- range $g.Details}}
+{{.Rendered}}{{end}}
+{{- else}}{{range $j, $e := $g.Events}}{{if $j}}
+{{end}}{{$e.Rendered}}{{end}}{{end}}{{end}}`
+
+const batchANSITpl = `{{range $i, $g := .GroupBy "project"}}{{if $i}}
+{{end}}{{if and $g.Key (gt (len $g.Events) 1)}}{{with $g.Host}}{{Magenta}}[{{.}}]{{Reset}} {{end}}{{T "template.project"}} {{Blue}}{{$g.Key}}{{Reset}}: {{range $j, $c := $g.Summary}}{{if $j}}, {{end}}{{$c.Count}} {{Yellow}}{{PastTense $c.Action}}{{Reset}}{{end}}
+{{- with $g.Failures}} ({{range $k, $f := .}}{{if $k}}, {{end}}{{Magenta}}{{or $f.Service $f.Name}}{{Reset}} {{T "template.exit"}} {{Red}}{{$f.ExitCode}}{{Reset}}{{end}}){{end}}
+{{- range $g.Details}}
+{{.Rendered}}{{end}}
+{{- else}}{{range $j, $e := $g.Events}}{{if $j}}
+{{end}}{{$e.Rendered}}{{end}}{{end}}{{end}}`
+
+// BatchEvent is an event together with its rendering by the per-event template
+type BatchEvent struct {
+	Event
+	Rendered string
+}
+
+type Batch struct {
+	Events []BatchEvent
+}
+
+// EventGroup holds events of one host sharing the same value of the grouping field,
+// Key is empty for events without it
+type EventGroup struct {
+	Host   string
+	Key    string
+	Events []BatchEvent
+}
+
+type ActionCount struct {
+	Action string
+	Count  int
+}
+
+// GroupBy groups events by "project", "service", "action", "type", "name" or "host",
+// keeping the order in which groups first appear. Events of different hosts are never
+// grouped together, compose projects of the same name on two hosts are unrelated.
+func (b Batch) GroupBy(field string) []EventGroup {
+	type groupID struct{ host, key string }
+
+	var groups []EventGroup
+	index := map[groupID]int{}
+
+	for _, e := range b.Events {
+		id := groupID{e.Host, e.groupKey(field)}
+		i, ok := index[id]
+		if !ok {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, EventGroup{Host: id.host, Key: id.key})
+		}
+		groups[i].Events = append(groups[i].Events, e)
+	}
+
+	return groups
+}
+
+func (e Event) groupKey(field string) string {
+	switch field {
+	case "project":
+		return e.Project
+	case "service":
+		return e.Service
+	case "action":
+		return e.Action
+	case "type":
+		return e.Type
+	case "name":
+		return e.Name
+	case "host":
+		return e.Host
+	}
+	return ""
+}
+
+// Summary counts events per action in order of first appearance
+func (g EventGroup) Summary() []ActionCount {
+	var counts []ActionCount
+	index := map[string]int{}
+
+	for _, e := range g.Events {
+		i, ok := index[e.Action]
+		if !ok {
+			i = len(counts)
+			index[e.Action] = i
+			counts = append(counts, ActionCount{Action: e.Action})
+		}
+		counts[i].Count++
+	}
+
+	return counts
+}
+
+// Failures are containers that stopped with a non-zero exit code
+func (g EventGroup) Failures() []BatchEvent {
+	var failures []BatchEvent
+	for _, e := range g.Events {
+		if e.Action == "die" && e.ExitCode != "" && e.ExitCode != "0" {
+			failures = append(failures, e)
+		}
+	}
+	return failures
+}
+
+// Details are the events of a summarized group that keep their own line:
+// failures and events with logs, health check output or an OOM kill
+func (g EventGroup) Details() []BatchEvent {
+	var details []BatchEvent
+	for _, e := range g.Events {
+		if e.Severity() == SeverityCritical || e.Logs != "" || len(e.HealthLog) > 0 || e.OOMKilled {
+			details = append(details, e)
+		}
+	}
+	return details
+}
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deployEvents() []Event {
+	var events []Event
+	for _, service := range []string{"api", "web", "worker"} {
+		events = append(events, Event{Type: "container", Action: "start", Name: "shop-" + service + "-1", Image: service, Project: "shop", Service: service})
+	}
+	events = append(events,
+		Event{Type: "container", Action: "die", Name: "shop-api-1", Image: "api", Project: "shop", Service: "api", ExitCode: "137"},
+		Event{Type: "container", Action: "die", Name: "shop-web-1", Image: "web", Project: "shop", Service: "web", ExitCode: "0"},
+		Event{Type: "container", Action: "start", Name: "standalone", Image: "redis"},
+	)
+	return events
+}
+
+func TestRenderBatch_GroupsByProject(t *testing.T) {
+	templates := DefaultTemplates()
+	// the failure keeps its own line below the summary
+	failure := deployEvents()[3]
+
+	assert.Equal(t,
+		"project shop: 3 started, 2 stopped (api exit 137)\n"+
+			templates.Render(FormatText, failure)+"\n"+
+			"container start standalone (redis)",
+		templates.RenderBatch(FormatText, deployEvents()))
+
+	assert.Equal(t,
+		"project `shop`: 3 *started*, 2 *stopped* (`api` exit `137`)\n"+
+			templates.Render(FormatMarkdown, failure)+"\n"+
+			"container *start* `standalone` (`redis`)",
+		templates.RenderBatch(FormatMarkdown, deployEvents()))
+
+	assert.Equal(t,
+		"project <code>shop</code>: 3 <b>started</b>, 2 <b>stopped</b> (<code>api</code> exit <code>137</code>)\n"+
+			templates.Render(FormatHTML, failure)+"\n"+
+			"container <b>start</b> <code>standalone</code> (<code>redis</code>)",
+		templates.RenderBatch(FormatHTML, deployEvents()))
+}
+
+func TestRenderBatch_GroupKeepsDetails(t *testing.T) {
+	events := []Event{
+		{
+			Type: "container", Action: "die", Name: "shop-api-1", Image: "api", Project: "shop", Service: "api",
+			ExitCode: "137", OOMKilled: true, MemoryLimit: 512 << 20, Logs: "fatal error: runtime: out of memory",
+		},
+		{
+			Type: "container", Action: "health_status: unhealthy", Name: "shop-web-1", Image: "web", Project: "shop", Service: "web",
+			HealthLog: []HealthCheck{{End: 1700000000, ExitCode: 1, Output: "connection refused"}}, HealthFailingStreak: 3,
+		},
+	}
+
+	for _, format := range Formats {
+		result := DefaultTemplates().RenderBatch(format, events)
+		assert.Contains(t, result, "became unhealthy", format)
+		assert.Contains(t, result, "fatal error: runtime: out of memory", format)
+		assert.Contains(t, result, "killed: out of memory", format)
+		assert.Contains(t, result, "(limit 512MiB)", format)
+		assert.Contains(t, result, "connection refused", format)
+	}
+}
+
+func TestRenderBatch_SingleEventsAreNotSummarized(t *testing.T) {
+	events := []Event{
+		{Type: "container", Action: "die", Name: "api", Image: "api", Project: "shop", Service: "api", ExitCode: "1"},
+		{Type: "container", Action: "start", Name: "db", Image: "postgres", Project: "billing", Service: "db"},
+	}
+
+	result := DefaultTemplates().RenderBatch(FormatText, events)
+	assert.Equal(t, events[0].Text()+"\n"+events[1].Text(), result)
+
+	assert.Equal(t, events[0].Text(), DefaultTemplates().RenderBatch(FormatText, events[:1]))
+}
+
+func TestBatch_GroupBy(t *testing.T) {
+	batch := Batch{}
+	for _, e := range deployEvents() {
+		batch.Events = append(batch.Events, BatchEvent{Event: e})
+	}
+
+	byService := batch.GroupBy("service")
+	assert.Len(t, byService, 4)
+	assert.Equal(t, "api", byService[0].Key)
+	assert.Len(t, byService[0].Events, 2)
+	assert.Equal(t, "", byService[3].Key)
+
+	byAction := batch.GroupBy("action")
+	assert.Len(t, byAction, 2)
+	assert.Equal(t, []ActionCount{{Action: "start", Count: 4}}, byAction[0].Summary())
+	assert.Len(t, byAction[1].Failures(), 1)
+}
+
+func TestBatch_GroupBySeparatesHosts(t *testing.T) {
+	var events []Event
+	for _, host := range []string{"web1", "web2"} {
+		for _, e := range deployEvents()[:4] {
+			e.Host = host
+			events = append(events, e)
+		}
+	}
+
+	batch := Batch{}
+	for _, e := range events {
+		batch.Events = append(batch.Events, BatchEvent{Event: e})
+	}
+
+	byProject := batch.GroupBy("project")
+	assert.Len(t, byProject, 2)
+	for i, host := range []string{"web1", "web2"} {
+		assert.Equal(t, host, byProject[i].Host)
+		assert.Equal(t, "shop", byProject[i].Key)
+		assert.Len(t, byProject[i].Events, 4)
+		assert.Len(t, byProject[i].Failures(), 1)
+	}
+
+	result := DefaultTemplates().RenderBatch(FormatText, events)
+	assert.Contains(t, result, "[web1] project shop: 3 started, 1 stopped (api exit 137)")
+	assert.Contains(t, result, "[web2] project shop: 3 started, 1 stopped (api exit 137)")
+}
@@ -7,6 +7,7 @@ import (
 )
 
 type ConsoleNotifier struct {
+	templated
 	prefix  string
 	colored bool
 }
@@ -40,12 +41,12 @@ func (c *ConsoleNotifier) Notify(ctx context.Context, event Event, debug bool) e
 	if c.colored {
 		message = fmt.Sprintf(Yellow+"[%s]"+Reset+" %s",
 			c.prefix,
-			event.ANSI(),
+			c.render(FormatANSI, event),
 		)
 	} else {
 		message = fmt.Sprintf("[%s] %s",
 			c.prefix,
-			event.Text(),
+			c.render(FormatText, event),
 		)
 	}
 
@@ -2,8 +2,12 @@ package notifications
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/lotas/docker-alerts/internal/metrics"
 )
 
 type DebouncerNotifier struct {
@@ -32,10 +36,13 @@ func NewDebouncerNotifier(notifier Notifier, minInterval time.Duration) *Debounc
 
 func (d *DebouncerNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
 	// shouldn't be really called but ok
+	var errs []error
 	for _, n := range events {
-		d.Notify(ctx, n, debug)
+		if err := d.Notify(ctx, n, debug); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (d *DebouncerNotifier) Notify(ctx context.Context, n Event, debug bool) error {
@@ -43,13 +50,13 @@ func (d *DebouncerNotifier) Notify(ctx context.Context, n Event, debug bool) err
 	defer d.mu.Unlock()
 
 	d.events = append(d.events, n)
+	metrics.DebouncerBuffered.Set(float64(len(d.events)))
 	d.ctx = ctx
 	d.debug = debug
 
 	timeElapsed := time.Since(d.lastSent)
 	if timeElapsed >= d.minInterval {
-		d.sendAllLocked()
-		return nil
+		return d.sendAllLocked()
 	}
 
 	if !d.isScheduled {
@@ -64,7 +71,10 @@ func (d *DebouncerNotifier) Notify(ctx context.Context, n Event, debug bool) err
 			defer d.mu.Unlock()
 
 			d.isScheduled = false
-			d.sendAllLocked()
+			// nobody waits for a delayed send, failures are counted per notifier
+			if err := d.sendAllLocked(); err != nil {
+				fmt.Printf("Failed to send debounced events: %v\n", err)
+			}
 		})
 
 		d.isScheduled = true
@@ -74,15 +84,17 @@ func (d *DebouncerNotifier) Notify(ctx context.Context, n Event, debug bool) err
 }
 
 // must be called when lock is held
-func (d *DebouncerNotifier) sendAllLocked() {
+func (d *DebouncerNotifier) sendAllLocked() error {
 	if len(d.events) == 0 {
-		return
+		return nil
 	}
 
-	d.notifier.NotifyMultiple(d.ctx, d.events, d.debug)
+	err := d.notifier.NotifyMultiple(d.ctx, d.events, d.debug)
 
 	d.lastSent = time.Now()
 	d.events = nil
+	metrics.DebouncerBuffered.Set(0)
+	return err
 }
 
 func (d *DebouncerNotifier) Close() {
@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebouncerNotifier_FlushError(t *testing.T) {
+	failing := &failingNotifier{err: errors.New("rate limited")}
+	debouncer := NewDebouncerNotifier(failing, time.Hour)
+	defer debouncer.Close()
+
+	// the first event is sent right away and its error is returned
+	assert.ErrorContains(t, debouncer.Notify(context.Background(), Event{Name: "a"}, false), "rate limited")
+	// later ones wait for the timer
+	assert.NoError(t, debouncer.Notify(context.Background(), Event{Name: "b"}, false))
+}
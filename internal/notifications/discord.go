@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"unicode/utf8"
+)
+
+// Discord rejects messages longer than this
+const discordMaxContentLength = 2000
+
+type DiscordNotifier struct {
+	templated
+	webhookURL string
+	client     *http.Client
+}
+
+type discordMessage struct {
+	Content string `json:"content"`
+}
+
+func NewDiscordNotifier(webhookID, webhookToken string) *DiscordNotifier {
+	return &DiscordNotifier{
+		webhookURL: fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", webhookID, webhookToken),
+		client:     &http.Client{},
+	}
+}
+
+func (d *DiscordNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return d.NotifyMultiple(ctx, []Event{event}, debug)
+}
+
+func (d *DiscordNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	render := func(events []Event) string {
+		return d.renderBatch(FormatMarkdown, events)
+	}
+	content := render(events)
+	attach := logsNotRendered(content, events, render)
+	if utf8.RuneCountInString(content) > discordMaxContentLength && hasLogs(events) {
+		// logs do not fit, attach them as files
+		content = render(withoutLogs(events))
+		attach = events
+	}
+
+	if files := logFiles(attach); len(files) > 0 {
+		return d.sendWithFiles(ctx, content, files, debug)
+	}
+	return d.send(ctx, content, debug)
+}
+
+func (d *DiscordNotifier) send(ctx context.Context, content string, debug bool) error {
+	if runes := []rune(content); len(runes) > discordMaxContentLength {
+		content = string(runes[:discordMaxContentLength-3]) + "..."
+	}
+
+	if err := postJSON(ctx, d.client, d.webhookURL, discordMessage{Content: content}, debug); err != nil {
+		return fmt.Errorf("failed to send discord message: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DiscordNotifier) sendWithFiles(ctx context.Context, content string, files []logFile, debug bool) error {
+	if runes := []rune(content); len(runes) > discordMaxContentLength {
+		content = string(runes[:discordMaxContentLength-3]) + "..."
+	}
+
+	payload, err := json.Marshal(discordMessage{Content: content})
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("payload_json", string(payload))
+	for i, file := range files {
+		part, err := writer.CreateFormFile(fmt.Sprintf("files[%d]", i), file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		part.Write([]byte(file.Content))
+	}
+	writer.Close()
+
+	if debug {
+		fmt.Printf("Posting discord message with %d files\n", len(files))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhookURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send discord message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send discord message: status code %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -1,26 +1,74 @@
 package notifications
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
 )
 
+type EmailTLSMode string
+
+const (
+	// EmailTLSNone sends everything in plain text
+	EmailTLSNone EmailTLSMode = "none"
+	// EmailTLSOpportunistic upgrades with STARTTLS when the server offers it
+	EmailTLSOpportunistic EmailTLSMode = "starttls"
+	// EmailTLSRequired fails when the server doesn't offer STARTTLS
+	EmailTLSRequired EmailTLSMode = "starttls-required"
+	// EmailTLSImplicit connects over TLS right away, usually on port 465
+	EmailTLSImplicit EmailTLSMode = "tls"
+)
+
+const defaultEmailTimeout = 30 * time.Second
+
 type EmailNotifier struct {
+	templated
 	host        string
 	port        int
 	fromAddress string
 	toAddresses []string
 	auth        smtp.Auth
+	tlsMode     EmailTLSMode
+	tlsConfig   *tls.Config
+	timeout     time.Duration
+	hostname    string
+	now         func() time.Time
 }
 
 func NewEmailNotifier(host string, port int, fromAddress string, toAddresses []string) *EmailNotifier {
+	tlsMode := EmailTLSOpportunistic
+	if port == 465 {
+		tlsMode = EmailTLSImplicit
+	}
+
 	return &EmailNotifier{
 		host:        host,
 		port:        port,
 		fromAddress: fromAddress,
 		toAddresses: toAddresses,
+		tlsMode:     tlsMode,
+		tlsConfig:   &tls.Config{ServerName: host},
+		timeout:     defaultEmailTimeout,
+		hostname:    localHostname(),
+		now:         time.Now,
 	}
 }
 
@@ -28,79 +76,357 @@ func (e *EmailNotifier) SetAuth(username, password string) {
 	e.auth = smtp.PlainAuth("", username, password, e.host)
 }
 
-func (e *EmailNotifier) Notify(ctx context.Context, event Event, debug bool) error {
-	subject := event.Type + " " + event.Action
-	body := event.Text()
-
-	message := []string{
-		"From: " + e.fromAddress,
-		"To: " + strings.Join(e.toAddresses, ","),
-		"Subject: " + subject,
-		"MIME-Version: 1.0",
-		"Content-Type: text/plain; charset=utf-8",
-		"",
-		body,
+// SetAuthMechanism selects one of "plain", "login" or "cram-md5"
+func (e *EmailNotifier) SetAuthMechanism(mechanism, username, password string) error {
+	switch strings.ToLower(mechanism) {
+	case "", "plain":
+		e.auth = smtp.PlainAuth("", username, password, e.host)
+	case "login":
+		e.auth = &loginAuth{username: username, password: password, host: e.host}
+	case "cram-md5":
+		e.auth = smtp.CRAMMD5Auth(username, password)
+	default:
+		return fmt.Errorf("unsupported SMTP auth mechanism %q", mechanism)
 	}
+	return nil
+}
 
-	emailBody := strings.Join(message, "\r\n")
+// SetTLS configures how the connection is secured. caFile adds a custom CA
+// for internal relays, insecureSkipVerify disables certificate checks entirely.
+func (e *EmailNotifier) SetTLS(mode EmailTLSMode, caFile string, insecureSkipVerify bool) error {
+	switch mode {
+	case "":
+	case EmailTLSNone, EmailTLSOpportunistic, EmailTLSRequired, EmailTLSImplicit:
+		e.tlsMode = mode
+	default:
+		return fmt.Errorf("unsupported SMTP TLS mode %q", mode)
+	}
 
-	addr := fmt.Sprintf("%s:%d", e.host, e.port)
+	e.tlsConfig = &tls.Config{
+		ServerName:         e.host,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
 
-	var err error
-	if e.auth != nil {
-		err = smtp.SendMail(
-			addr,
-			e.auth,
-			e.fromAddress,
-			e.toAddresses,
-			[]byte(emailBody),
-		)
-	} else {
-		// Send without authentication
-		client, err := smtp.Dial(addr)
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
 		if err != nil {
-			return fmt.Errorf("failed to connect to SMTP server: %w", err)
+			return fmt.Errorf("failed to read CA file: %w", err)
 		}
-		defer client.Close()
 
-		if err := client.Mail(e.fromAddress); err != nil {
-			return fmt.Errorf("failed to set sender: %w", err)
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", caFile)
 		}
+		e.tlsConfig.RootCAs = pool
+	}
+
+	return nil
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return e.NotifyMultiple(ctx, []Event{event}, debug)
+}
+
+// NotifyMultiple sends all events as a single digest email
+func (e *EmailNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	hostname := e.hostname
+	if host := commonHost(events); host != "" {
+		hostname = host
+	}
+	message, err := e.buildMessage(digestSubject(e.catalog(), events, hostname), events)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if debug {
+		fmt.Printf("Sending email to %v\n", e.toAddresses)
+	}
+
+	if err := e.send(ctx, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (e *EmailNotifier) send(ctx context.Context, message []byte) error {
+	if len(e.toAddresses) == 0 {
+		return fmt.Errorf("failed to send email: no recipients")
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	conn, err := e.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	// smtp.Client has no context support, unblock it by closing the connection
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
 
-		for _, addr := range e.toAddresses {
-			if err := client.Rcpt(addr); err != nil {
-				return fmt.Errorf("failed to add recipient %s: %w", addr, err)
+	if err := client.Hello(e.hostname); err != nil {
+		return fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+
+	if e.tlsMode == EmailTLSOpportunistic || e.tlsMode == EmailTLSRequired {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok && e.tlsMode == EmailTLSRequired {
+			return fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if ok {
+			if err := client.StartTLS(e.tlsConfig); err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
 			}
 		}
+	}
 
-		w, err := client.Data()
-		if err != nil {
-			return fmt.Errorf("failed to start email data: %w", err)
+	if e.auth != nil {
+		if err := client.Auth(e.auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
 		}
+	}
 
-		_, err = w.Write([]byte(emailBody))
-		if err != nil {
-			return fmt.Errorf("failed to write email body: %w", err)
+	if err := client.Mail(e.fromAddress); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+
+	// keep going when some recipients are rejected, report them all at the end
+	var errs []error
+	accepted := 0
+	for _, addr := range e.toAddresses {
+		if err := client.Rcpt(addr); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add recipient %s: %w", addr, err))
+			continue
 		}
+		accepted++
+	}
+	if accepted == 0 {
+		return errors.Join(errs...)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to start email data: %w", err))...)
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to write email body: %w", err))...)
+	}
 
-		err = w.Close()
+	if err := w.Close(); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to close email writer: %w", err))...)
+	}
+
+	if err := client.Quit(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close connection: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *EmailNotifier) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+
+	if e.tlsMode == EmailTLSImplicit {
+		dialer := &tls.Dialer{Config: e.tlsConfig}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// loginAuth implements the non-standard but widely used LOGIN mechanism
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// same rules as smtp.PlainAuth: never send credentials in the clear
+	// unless talking to localhost
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, fmt.Errorf("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, fmt.Errorf("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+func (e *EmailNotifier) buildMessage(subject string, events []Event) ([]byte, error) {
+	text := e.renderBatch(FormatText, events)
+	html := htmlLineBreaks(e.renderBatch(FormatHTML, events))
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + e.fromAddress,
+		"To: " + strings.Join(e.toAddresses, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + e.now().Format(time.RFC1123Z),
+		"Message-ID: " + e.messageID(),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+		"",
+		"",
+	}
+	message := []byte(strings.Join(headers, "\r\n"))
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", "<html><body>\n" + html + "\n</body></html>"},
+	}
+
+	for _, p := range parts {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
 		if err != nil {
-			return fmt.Errorf("failed to close email writer: %w", err)
+			return nil, err
 		}
 
-		err = client.Quit()
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	if err := body.Close(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return append(message, buf.Bytes()...), nil
 }
 
-func (c *EmailNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
-	for _, n := range events {
-		c.Notify(ctx, n, debug)
+func (e *EmailNotifier) messageID() string {
+	random := make([]byte, 8)
+	rand.Read(random)
+
+	return fmt.Sprintf("<%d.%s@%s>", e.now().UnixNano(), hex.EncodeToString(random), e.hostname)
+}
+
+// pastTense returns the localized summary of a container action, e.g. "stopped"
+func pastTense(catalog *i18n.Catalog, e Event) (string, bool) {
+	if e.Type != "container" {
+		return "", false
 	}
-	return nil
+	if e.OOMKilled && e.Action == "die" {
+		return catalog.Lookup("action_past.oom")
+	}
+	return catalog.Lookup("action_past." + e.Action)
+}
+
+// eventTitle names events without a past tense summary, e.g. "Server info"
+func eventTitle(catalog *i18n.Catalog, e Event) string {
+	if e.ServerInfo != nil {
+		if e.Type == "summary" {
+			return catalog.T("report.title")
+		}
+		return catalog.T("info.title")
+	}
+	if e.Type == "daemon" {
+		action, _ := catalog.Lookup("action." + e.Action)
+		return catalog.T("summary.single", catalog.T("daemon.title"), action)
+	}
+	if e.Disk != nil {
+		action, _ := catalog.Lookup("action." + e.Action)
+		return catalog.T("summary.single", catalog.T("disk.title"), action)
+	}
+	return strings.TrimSpace(e.Type + " " + e.Action)
+}
+
+// digestSubject summarizes a batch, e.g. "3 containers stopped on host-x"
+func digestSubject(catalog *i18n.Catalog, events []Event, hostname string) string {
+	if len(events) == 1 {
+		e := events[0]
+		if summary, ok := pastTense(catalog, e); ok {
+			return catalog.T("summary.on_host", catalog.T("summary.single", e.Name, summary), hostname)
+		}
+		return catalog.T("summary.on_host", eventTitle(catalog, e), hostname)
+	}
+
+	counts := map[string]int{}
+	for _, e := range events {
+		summary, ok := pastTense(catalog, e)
+		if !ok {
+			summary = eventTitle(catalog, e)
+		}
+		counts[summary]++
+	}
+
+	if len(counts) == 1 {
+		for summary, count := range counts {
+			if _, known := pastTense(catalog, events[0]); known {
+				return catalog.T("summary.on_host", catalog.T("summary.containers", count, summary), hostname)
+			}
+			return catalog.T("summary.events_on_host", count, hostname, summary)
+		}
+	}
+
+	summaries := make([]string, 0, len(counts))
+	for summary := range counts {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if counts[summaries[i]] != counts[summaries[j]] {
+			return counts[summaries[i]] > counts[summaries[j]]
+		}
+		return summaries[i] < summaries[j]
+	})
+
+	details := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		details = append(details, fmt.Sprintf("%d %s", counts[summary], summary))
+	}
+
+	return catalog.T("summary.events_on_host", len(events), hostname, strings.Join(details, ", "))
 }
@@ -0,0 +1,333 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
+)
+
+// fakeSMTPServer is a minimal SMTP server accepting a single session at a time.
+type fakeSMTPServer struct {
+	listener net.Listener
+	reject   map[string]bool
+
+	mu       sync.Mutex
+	commands []string
+	rcpts    []string
+	data     []string
+}
+
+func newFakeSMTPServer(t *testing.T, reject ...string) *fakeSMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{listener: l, reject: map[string]bool{}}
+	for _, r := range reject {
+		s.reject[r] = true
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) hostPort(t *testing.T) (string, int) {
+	host, portStr, err := net.SplitHostPort(s.listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return host, port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN LOGIN CRAM-MD5")
+		case strings.HasPrefix(cmd, "AUTH LOGIN"):
+			reply("334 VXNlcm5hbWU6")
+			r.ReadString('\n')
+			reply("334 UGFzc3dvcmQ6")
+			r.ReadString('\n')
+			reply("235 Authentication successful")
+		case strings.HasPrefix(cmd, "AUTH"):
+			reply("235 Authentication successful")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			addr := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if s.reject[addr] {
+				reply("550 No such user")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, addr)
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailNotifier_NotifyMultiple(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	notifier := NewEmailNotifier(host, port, "alerts@example.com", []string{"ops@example.com", "dev@example.com"})
+	notifier.hostname = "host-x"
+
+	events := []Event{
+		{Type: "container", Action: "die", Name: "api", Image: "api:1", ExitCode: "137"},
+		{Type: "container", Action: "die", Name: "web", Image: "web:1", ExitCode: "0"},
+		{Type: "container", Action: "die", Name: "db", Image: "postgres", ExitCode: "1"},
+	}
+
+	err := notifier.NotifyMultiple(context.Background(), events, false)
+	require.NoError(t, err)
+
+	require.Len(t, server.data, 1, "expected a single digest email")
+	assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, server.rcpts)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data[0]))
+	require.NoError(t, err)
+
+	assert.Equal(t, "3 containers stopped on host-x", msg.Header.Get("Subject"))
+	assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+	_, err = msg.Header.Date()
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	bodies := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(content)
+	}
+
+	assert.Contains(t, bodies["text/plain"], "container stop api (api:1)")
+	assert.Contains(t, bodies["text/plain"], "container stop db (postgres)")
+	assert.Contains(t, bodies["text/html"], "<code>api</code>")
+}
+
+func TestEmailNotifier_RejectedRecipients(t *testing.T) {
+	server := newFakeSMTPServer(t, "bad@example.com", "worse@example.com")
+	host, port := server.hostPort(t)
+
+	notifier := NewEmailNotifier(host, port, "alerts@example.com",
+		[]string{"bad@example.com", "ops@example.com", "worse@example.com"})
+
+	err := notifier.Notify(context.Background(), Event{Type: "container", Action: "start", Name: "api"}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad@example.com")
+	assert.Contains(t, err.Error(), "worse@example.com")
+
+	// the valid recipient still gets the message
+	assert.Equal(t, []string{"ops@example.com"}, server.rcpts)
+	assert.Len(t, server.data, 1)
+}
+
+func TestEmailNotifier_NoRecipients(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	notifier := NewEmailNotifier(host, port, "alerts@example.com", nil)
+	err := notifier.Notify(context.Background(), Event{Type: "container", Action: "start", Name: "api"}, false)
+	assert.ErrorContains(t, err, "no recipients")
+	assert.Empty(t, server.data)
+}
+
+func TestEmailNotifier_EncodedSubject(t *testing.T) {
+	notifier := NewEmailNotifier("localhost", 25, "alerts@example.com", []string{"ops@example.com"})
+	notifier.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	message, err := notifier.buildMessage("контейнер остановлен", []Event{{Message: "hi"}})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(message)))
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(msg.Header.Get("Subject"), "=?utf-8?q?"))
+	decoded, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "контейнер остановлен", decoded)
+	assert.Equal(t, "Thu, 02 Jan 2025 03:04:05 +0000", msg.Header.Get("Date"))
+}
+
+func TestDigestSubject(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []Event
+		expected string
+	}{
+		{
+			name:     "single container event",
+			events:   []Event{{Type: "container", Action: "die", Name: "api"}},
+			expected: "api stopped on host-x",
+		},
+		{
+			name:     "single message",
+			events:   []Event{{Type: "Server info", Message: "Docker version: 27"}},
+			expected: "Server info on host-x",
+		},
+		{
+			name: "same action",
+			events: []Event{
+				{Type: "container", Action: "start", Name: "a"},
+				{Type: "container", Action: "start", Name: "b"},
+			},
+			expected: "2 containers started on host-x",
+		},
+		{
+			name: "mixed actions",
+			events: []Event{
+				{Type: "container", Action: "start", Name: "a"},
+				{Type: "container", Action: "die", Name: "b"},
+				{Type: "container", Action: "die", Name: "c"},
+			},
+			expected: "3 events on host-x: 2 stopped, 1 started",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, digestSubject(i18n.English(), tc.events, "host-x"))
+		})
+	}
+}
+
+func TestEmailNotifier_LoginAuth(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	notifier := NewEmailNotifier(host, port, "alerts@example.com", []string{"ops@example.com"})
+	require.NoError(t, notifier.SetAuthMechanism("login", "user", "secret"))
+
+	err := notifier.Notify(context.Background(), Event{Type: "container", Action: "start", Name: "api"}, false)
+	require.NoError(t, err)
+	assert.Contains(t, server.commands, "AUTH LOGIN")
+	assert.Len(t, server.data, 1)
+}
+
+func TestEmailNotifier_StartTLSRequired(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	notifier := NewEmailNotifier(host, port, "alerts@example.com", []string{"ops@example.com"})
+	require.NoError(t, notifier.SetTLS(EmailTLSRequired, "", false))
+
+	err := notifier.Notify(context.Background(), Event{Type: "container", Action: "start", Name: "api"}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support STARTTLS")
+	assert.Empty(t, server.data)
+}
+
+func TestEmailNotifier_ContextTimeout(t *testing.T) {
+	// accepts connections but never greets
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	notifier := NewEmailNotifier("127.0.0.1", addr.Port, "alerts@example.com", []string{"ops@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = notifier.Notify(ctx, Event{Type: "container", Action: "start", Name: "api"}, false)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestEmailNotifier_InvalidSettings(t *testing.T) {
+	notifier := NewEmailNotifier("mail.example.com", 587, "alerts@example.com", nil)
+	assert.Error(t, notifier.SetAuthMechanism("xoauth3", "user", "pass"))
+	assert.Error(t, notifier.SetTLS("maybe", "", false))
+	assert.Error(t, notifier.SetTLS(EmailTLSRequired, "/does/not/exist.pem", false))
+
+	assert.Equal(t, EmailTLSImplicit, NewEmailNotifier("mail.example.com", 465, "", nil).tlsMode)
+}
@@ -0,0 +1,133 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecNotifier runs an external command for every event. The event is passed
+// as JSON on stdin and as DA_EVENT_* environment variables.
+type ExecNotifier struct {
+	templated
+	command []string
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+type ExecOption func(*ExecNotifier)
+
+func WithExecTimeout(timeout time.Duration) ExecOption {
+	return func(n *ExecNotifier) {
+		if timeout > 0 {
+			n.timeout = timeout
+		}
+	}
+}
+
+func WithExecConcurrency(limit int) ExecOption {
+	return func(n *ExecNotifier) {
+		if limit > 0 {
+			n.slots = make(chan struct{}, limit)
+		}
+	}
+}
+
+func NewExecNotifier(command []string, opts ...ExecOption) *ExecNotifier {
+	n := &ExecNotifier{
+		command: command,
+		timeout: 10 * time.Second,
+		slots:   make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+func (n *ExecNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	if len(n.command) == 0 {
+		return fmt.Errorf("exec notifier has no command configured")
+	}
+
+	select {
+	case n.slots <- struct{}{}:
+		defer func() { <-n.slots }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(runCtx, n.command[0], n.command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), eventEnv(event, n.render(FormatText, event))...)
+
+	if debug {
+		fmt.Printf("Running exec notifier %v\n", n.command)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", n.timeout)
+		}
+		output := strings.TrimSpace(stderr.String())
+		fmt.Printf("Exec notifier %q failed: %v\n%s\n", n.command[0], err, output)
+		return fmt.Errorf("exec notifier command %q failed: %w", n.command[0], err)
+	}
+
+	return nil
+}
+
+// NotifyMultiple runs the command for every event, at most --exec-concurrency at a time
+func (n *ExecNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	errs := make([]error, len(events))
+	var wg sync.WaitGroup
+	for i, e := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = n.Notify(ctx, e, debug)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func eventEnv(e Event, text string) []string {
+	env := []string{
+		"DA_EVENT_TYPE=" + e.Type,
+		"DA_EVENT_ACTION=" + e.Action,
+		"DA_EVENT_CONTAINER=" + e.Container,
+		"DA_EVENT_IMAGE=" + e.Image,
+		fmt.Sprintf("DA_EVENT_TIME=%d", e.Time),
+		"DA_EVENT_STATUS=" + e.Status,
+		"DA_EVENT_NAME=" + e.Name,
+		"DA_EVENT_PROJECT=" + e.Project,
+		"DA_EVENT_SERVICE=" + e.Service,
+		"DA_EVENT_EXIT_CODE=" + e.ExitCode,
+		"DA_EVENT_EXIT_CODE_DETAILS=" + e.ExitCodeDetails,
+		"DA_EVENT_EXEC_DURATION=" + e.ExecDuration,
+		"DA_EVENT_MESSAGE=" + e.Message,
+		"DA_EVENT_TEXT=" + text,
+	}
+
+	return env
+}
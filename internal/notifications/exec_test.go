@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecNotifier_Notify(t *testing.T) {
+	event := Event{
+		Type:     "container",
+		Action:   "die",
+		Name:     "web-server",
+		Image:    "nginx:latest",
+		ExitCode: "137",
+	}
+
+	t.Run("passes event on stdin and env", func(t *testing.T) {
+		dir := t.TempDir()
+		stdinFile := filepath.Join(dir, "stdin.json")
+		envFile := filepath.Join(dir, "env.txt")
+
+		notifier := NewExecNotifier([]string{"sh", "-c",
+			`cat > "$0"; echo "$DA_EVENT_NAME $DA_EVENT_ACTION $DA_EVENT_EXIT_CODE" > "$1"`,
+			stdinFile, envFile,
+		})
+
+		err := notifier.Notify(context.Background(), event, false)
+		require.NoError(t, err)
+
+		raw, err := os.ReadFile(stdinFile)
+		require.NoError(t, err)
+		var got Event
+		require.NoError(t, json.Unmarshal(raw, &got))
+		assert.Equal(t, event, got)
+
+		env, err := os.ReadFile(envFile)
+		require.NoError(t, err)
+		assert.Equal(t, "web-server die 137\n", string(env))
+	})
+
+	t.Run("non-zero exit is a failure", func(t *testing.T) {
+		notifier := NewExecNotifier([]string{"sh", "-c", "echo boom >&2; exit 3"})
+
+		err := notifier.Notify(context.Background(), event, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exit status 3")
+	})
+
+	t.Run("timeout kills the command", func(t *testing.T) {
+		notifier := NewExecNotifier([]string{"sleep", "5"},
+			WithExecTimeout(50*time.Millisecond),
+		)
+
+		start := time.Now()
+		err := notifier.Notify(context.Background(), event, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("no command configured", func(t *testing.T) {
+		notifier := NewExecNotifier(nil)
+		err := notifier.Notify(context.Background(), event, false)
+		require.Error(t, err)
+	})
+}
+
+func TestExecNotifier_NotifyMultiple(t *testing.T) {
+	notifier := NewExecNotifier([]string{"sh", "-c", `test "$DA_EVENT_NAME" = ok`},
+		WithExecConcurrency(2),
+	)
+
+	events := []Event{
+		{Type: "container", Action: "start", Name: "ok"},
+		{Type: "container", Action: "start", Name: "bad"},
+		{Type: "container", Action: "start", Name: "worse"},
+	}
+
+	err := notifier.NotifyMultiple(context.Background(), events, false)
+	require.Error(t, err)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+}
+
+func TestExecNotifier_Concurrency(t *testing.T) {
+	dir := t.TempDir()
+	// every run marks itself running, records how many runs it sees and stays a while
+	script := `touch "$DIR/run.$DA_EVENT_NAME"; ls "$DIR" | grep -c '^run\.' >> "$DIR/seen"; sleep 0.3; rm "$DIR/run.$DA_EVENT_NAME"`
+	t.Setenv("DIR", dir)
+	notifier := NewExecNotifier([]string{"sh", "-c", script}, WithExecConcurrency(2))
+
+	var events []Event
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		events = append(events, Event{Type: "container", Action: "start", Name: name})
+	}
+	require.NoError(t, notifier.NotifyMultiple(context.Background(), events, false))
+
+	data, err := os.ReadFile(filepath.Join(dir, "seen"))
+	require.NoError(t, err)
+	counts := strings.Fields(string(data))
+	require.Len(t, counts, 5)
+	assert.NotContains(t, counts, "3")
+	assert.Contains(t, counts, "2", "events of a batch should run in parallel")
+}
@@ -0,0 +1,269 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends one JSON object per event to a file and rotates it
+// once it grows past maxSize or gets older than maxAge.
+type FileNotifier struct {
+	templated
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	retention  time.Duration
+	hostname   string
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+type FileOption func(*FileNotifier)
+
+func WithMaxSize(bytes int64) FileOption {
+	return func(n *FileNotifier) {
+		n.maxSize = bytes
+	}
+}
+
+func WithMaxAge(age time.Duration) FileOption {
+	return func(n *FileNotifier) {
+		n.maxAge = age
+	}
+}
+
+// WithRetention keeps at most maxBackups rotated files, none older than age.
+// Zero values disable the respective limit.
+func WithRetention(maxBackups int, age time.Duration) FileOption {
+	return func(n *FileNotifier) {
+		n.maxBackups = maxBackups
+		n.retention = age
+	}
+}
+
+type fileRecord struct {
+	Event
+	Text string `json:"text"`
+
+	NotifiedAt time.Time `json:"notified_at"`
+	Hostname   string    `json:"hostname,omitempty"`
+	BatchSize  int       `json:"batch_size"`
+	BatchIndex int       `json:"batch_index"`
+}
+
+const rotatedTimeFormat = "20060102-150405.000000000"
+
+// pruneInterval is how often the rotated files are checked for expiry between rotations
+const pruneInterval = time.Minute
+
+func NewFileNotifier(path string, opts ...FileOption) *FileNotifier {
+	n := &FileNotifier{
+		path:     path,
+		hostname: localHostname(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return n.NotifyMultiple(ctx, []Event{event}, debug)
+}
+
+func (n *FileNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	notifiedAt := n.now().UTC()
+	for i, e := range events {
+		line, err := json.Marshal(fileRecord{
+			Event:      e,
+			Text:       n.render(FormatText, e),
+			NotifiedAt: notifiedAt,
+			Hostname:   n.hostname,
+			BatchSize:  len(events),
+			BatchIndex: i,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		line = append(line, '\n')
+
+		if err := n.rotateIfNeededLocked(int64(len(line)), debug); err != nil {
+			return err
+		}
+
+		written, err := n.file.Write(line)
+		n.size += int64(written)
+		if err != nil {
+			return fmt.Errorf("failed to write event to %s: %w", n.path, err)
+		}
+	}
+
+	return nil
+}
+
+func (n *FileNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.file == nil {
+		return nil
+	}
+	err := n.file.Close()
+	n.file = nil
+	return err
+}
+
+// must be called when lock is held
+func (n *FileNotifier) openLocked(debug bool) error {
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", n.path, err)
+	}
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", n.path, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat %s: %w", n.path, err)
+	}
+
+	n.file = f
+	n.size = info.Size()
+	n.openedAt = n.now()
+	if n.size > 0 {
+		// the file was left by a previous run, its segment started with the first record
+		n.openedAt = segmentStart(n.path, info.ModTime())
+	}
+
+	// expired backups of a previous run are removed without waiting for a rotation
+	n.removeExpiredLocked(debug)
+
+	return nil
+}
+
+// segmentStart is the notified_at of the first record in path, fallback when it can't be read
+func segmentStart(path string, fallback time.Time) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return fallback
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return fallback
+	}
+
+	var record struct {
+		NotifiedAt time.Time `json:"notified_at"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil || record.NotifiedAt.IsZero() {
+		return fallback
+	}
+	return record.NotifiedAt
+}
+
+// must be called when lock is held
+func (n *FileNotifier) rotateIfNeededLocked(incoming int64, debug bool) error {
+	if n.file == nil {
+		if err := n.openLocked(debug); err != nil {
+			return err
+		}
+	} else if n.now().Sub(n.prunedAt) >= pruneInterval {
+		// low traffic files may not rotate for a long time
+		n.removeExpiredLocked(debug)
+	}
+
+	if n.size == 0 {
+		return nil
+	}
+
+	tooBig := n.maxSize > 0 && n.size+incoming > n.maxSize
+	tooOld := n.maxAge > 0 && n.now().Sub(n.openedAt) >= n.maxAge
+	if !tooBig && !tooOld {
+		return nil
+	}
+
+	if err := n.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", n.path, err)
+	}
+	n.file = nil
+
+	rotated := n.path + "." + n.now().UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(n.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", n.path, err)
+	}
+	if debug {
+		fmt.Printf("Rotated %s to %s\n", n.path, rotated)
+	}
+
+	return n.openLocked(debug)
+}
+
+// must be called when lock is held
+func (n *FileNotifier) removeExpiredLocked(debug bool) {
+	if n.maxBackups <= 0 && n.retention <= 0 {
+		return
+	}
+	n.prunedAt = n.now()
+
+	matches, err := filepath.Glob(n.path + ".*")
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path      string
+		rotatedAt time.Time
+	}
+
+	var backups []backup
+	for _, match := range matches {
+		rotatedAt, err := time.Parse(rotatedTimeFormat, strings.TrimPrefix(match, n.path+"."))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{match, rotatedAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+
+	for i, b := range backups {
+		expired := n.maxBackups > 0 && i >= n.maxBackups
+		expired = expired || n.retention > 0 && n.now().Sub(b.rotatedAt) > n.retention
+		if !expired {
+			continue
+		}
+
+		if err := os.Remove(b.path); err != nil {
+			fmt.Printf("Failed to remove old log file %s: %v\n", b.path, err)
+		} else if debug {
+			fmt.Printf("Removed old log file %s\n", b.path)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readJSONLines(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+func TestFileNotifier_NotifyMultiple(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	notifier := NewFileNotifier(path)
+	defer notifier.Close()
+
+	events := []Event{
+		{Type: "container", Action: "start", Name: "c1", Image: "img1", Labels: map[string]string{"a": "b"}},
+		{Type: "container", Action: "die", Name: "c2", Image: "img2", ExitCode: "1"},
+	}
+
+	err := notifier.NotifyMultiple(context.Background(), events, false)
+	require.NoError(t, err)
+
+	records := readJSONLines(t, path)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "c1", records[0]["name"])
+	assert.Equal(t, map[string]any{"a": "b"}, records[0]["labels"])
+	assert.Equal(t, "container start c1 (img1)", records[0]["text"])
+	assert.Equal(t, float64(2), records[0]["batch_size"])
+	assert.Equal(t, float64(0), records[0]["batch_index"])
+	assert.NotEmpty(t, records[0]["notified_at"])
+
+	assert.Equal(t, "1", records[1]["exit_code"])
+	assert.Equal(t, float64(1), records[1]["batch_index"])
+}
+
+func TestFileNotifier_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	notifier := NewFileNotifier(path,
+		WithMaxSize(300),
+		WithRetention(2, 0),
+	)
+	notifier.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	defer notifier.Close()
+
+	event := Event{Type: "container", Action: "start", Name: "web-server", Image: "nginx:latest"}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, notifier.Notify(context.Background(), event, false))
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2, "only the newest backups should be kept")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(300))
+}
+
+func TestFileNotifier_RotationByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	now := time.Now()
+	notifier := NewFileNotifier(path, WithMaxAge(time.Hour))
+	notifier.now = func() time.Time { return now }
+	defer notifier.Close()
+
+	event := Event{Type: "container", Action: "start", Name: "c1"}
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+	assert.Len(t, readJSONLines(t, path), 1)
+}
+
+func TestFileNotifier_RotationByAgeFromFirstRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"container","notified_at":"`+start.Format(time.RFC3339Nano)+`"}`+"\n"), 0o644))
+	// appended to shortly before the restart, the segment is older than that
+	require.NoError(t, os.Chtimes(path, start.Add(50*time.Minute), start.Add(50*time.Minute)))
+
+	notifier := NewFileNotifier(path, WithMaxAge(time.Hour))
+	notifier.now = func() time.Time { return start.Add(61 * time.Minute) }
+	defer notifier.Close()
+
+	event := Event{Type: "container", Action: "start", Name: "c1"}
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+	assert.Len(t, readJSONLines(t, path), 1)
+}
+
+func TestFileNotifier_RetentionWithoutRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	expired := path + "." + now.Add(-48*time.Hour).Format(rotatedTimeFormat)
+	recent := path + "." + now.Add(-12*time.Hour).Format(rotatedTimeFormat)
+	for _, backup := range []string{expired, recent} {
+		require.NoError(t, os.WriteFile(backup, []byte("{}\n"), 0o644))
+	}
+
+	notifier := NewFileNotifier(path, WithRetention(0, 24*time.Hour))
+	notifier.now = func() time.Time { return now }
+	defer notifier.Close()
+
+	event := Event{Type: "container", Action: "start", Name: "c1"}
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+
+	// expired backups of a previous run go when the file is opened
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, recent)
+
+	// and the others once they expire, without waiting for a rotation
+	now = now.Add(13 * time.Hour)
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+	assert.NoFileExists(t, recent)
+	assert.Len(t, readJSONLines(t, path), 2)
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lotas/docker-alerts/internal/metrics"
+)
+
+// InstrumentedNotifier records send latency and failures of a notifier and whether its last send worked
+type InstrumentedNotifier struct {
+	notifier Notifier
+	name     string
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+func NewInstrumentedNotifier(name string, notifier Notifier) *InstrumentedNotifier {
+	return &InstrumentedNotifier{notifier: notifier, name: name}
+}
+
+func (i *InstrumentedNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	if !i.accepts([]Event{event}) {
+		return nil
+	}
+	return i.observe(func() error {
+		return i.notifier.Notify(ctx, event, debug)
+	})
+}
+
+func (i *InstrumentedNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	if !i.accepts(events) {
+		return nil
+	}
+	return i.observe(func() error {
+		return i.notifier.NotifyMultiple(ctx, events, debug)
+	})
+}
+
+// accepts is false when a filter of a URL notifier, like hosts=, drops all events,
+// nothing is sent then and neither metrics nor readiness change
+func (i *InstrumentedNotifier) accepts(events []Event) bool {
+	filter, ok := i.notifier.(eventFilter)
+	if !ok {
+		return true
+	}
+	for _, e := range events {
+		if filter.accepts(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err is the error of the last send, nil when it succeeded or nothing was sent yet
+func (i *InstrumentedNotifier) Err() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.lastErr
+}
+
+func (i *InstrumentedNotifier) observe(send func() error) error {
+	start := time.Now()
+	err := send()
+	metrics.NotifierDuration.WithLabelValues(i.name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.NotifierFailures.WithLabelValues(i.name).Inc()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastErr = err
+	return err
+}
+
+// Readiness tells whether the notifiers of a CreateNotifier can deliver, console and file are not part of it
+type Readiness struct {
+	notifiers []*InstrumentedNotifier
+}
+
+// Err is nil when at least one external notifier can send,
+// or when only the console and file ones are configured
+func (r *Readiness) Err() error {
+	return ready(r.notifiers)
+}
+
+func ready(notifiers []*InstrumentedNotifier) error {
+	if len(notifiers) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, n := range notifiers {
+		err := n.Err()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", n.name, err))
+	}
+	return errors.Join(errs...)
+}
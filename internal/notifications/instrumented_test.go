@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/config"
+	"github.com/lotas/docker-alerts/internal/metrics"
+)
+
+type failingNotifier struct {
+	err error
+}
+
+func (f *failingNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return f.err
+}
+
+func (f *failingNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	return f.err
+}
+
+func TestInstrumentedNotifier(t *testing.T) {
+	ctx := context.Background()
+	slack := &failingNotifier{}
+	instrumentedSlack := NewInstrumentedNotifier("slack", slack)
+	instrumentedEmail := NewInstrumentedNotifier("email", &failingNotifier{err: errors.New("smtp down")})
+	notifiers := []*InstrumentedNotifier{instrumentedSlack, instrumentedEmail}
+
+	// nothing sent yet
+	assert.NoError(t, ready(notifiers))
+	assert.NoError(t, ready(nil))
+
+	slack.err = errors.New("rate limited")
+	assert.Error(t, instrumentedSlack.Notify(ctx, Event{}, false))
+	assert.Error(t, instrumentedEmail.NotifyMultiple(ctx, []Event{{}}, false))
+	err := ready(notifiers)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "slack: rate limited")
+	assert.Contains(t, err.Error(), "email: smtp down")
+
+	// one notifier that works is enough
+	slack.err = nil
+	require.NoError(t, instrumentedSlack.Notify(ctx, Event{}, false))
+	assert.NoError(t, ready(notifiers))
+}
+
+func TestCreateNotifier_Readiness(t *testing.T) {
+	dir := t.TempDir()
+
+	_, readiness, err := CreateNotifier(&config.Config{FilePath: filepath.Join(dir, "events.log"), NoDebounce: true})
+	require.NoError(t, err)
+	assert.Empty(t, readiness.notifiers, "file is not external")
+
+	_, readiness, err = CreateNotifier(&config.Config{
+		Notify:     []string{"file://" + filepath.Join(dir, "url.log"), "json://example.com/hook", "tgram://111:xxx/12345"},
+		NoDebounce: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, readiness.notifiers, 2)
+	assert.Equal(t, "json", readiness.notifiers[0].name)
+	assert.NoError(t, readiness.Err())
+
+	// every call has its own readiness
+	_, other, err := CreateNotifier(&config.Config{NoDebounce: true})
+	require.NoError(t, err)
+	assert.Empty(t, other.notifiers)
+	assert.Len(t, readiness.notifiers, 2)
+}
+
+func TestCreateNotifier_EmailWithoutRecipients(t *testing.T) {
+	_, _, err := CreateNotifier(&config.Config{EmailSMTPHost: "mail.example.com", EmailFrom: "alerts@example.com", EmailTo: []string{" "}})
+	assert.ErrorContains(t, err, "--email-to")
+}
+
+func TestInstrumentedNotifier_SkipsFilteredEvents(t *testing.T) {
+	ctx := context.Background()
+	failing := &failingNotifier{err: errors.New("smtp down")}
+	i := NewInstrumentedNotifier("filtered", NewHostFilterNotifier(failing, []string{"web1"}))
+
+	require.NoError(t, i.Notify(ctx, Event{Host: "db"}, false))
+	require.NoError(t, i.NotifyMultiple(ctx, []Event{{Host: "db"}, {Host: "db2"}}, false))
+	assert.Zero(t, testutil.ToFloat64(metrics.NotifierFailures.WithLabelValues("filtered")))
+	assert.NoError(t, i.Err())
+
+	assert.Error(t, i.NotifyMultiple(ctx, []Event{{Host: "db"}, {Host: "web1"}}, false))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.NotifierFailures.WithLabelValues("filtered")))
+	assert.Error(t, i.Err())
+}
+
+func TestCreateNotifier_URLSchemeNames(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	notifier, readiness, err := CreateNotifier(&config.Config{
+		Notify:         []string{" TGRAM://111:xxx/12345", "JSON://" + strings.TrimPrefix(server.URL, "http://") + "/hook"},
+		NotifierLocale: map[string]string{"tgram": "ru"},
+		NotifierHosts:  map[string]string{"json": "web1", "tgram": "web2"},
+		NoDebounce:     true,
+	})
+	require.NoError(t, err)
+	require.Len(t, readiness.notifiers, 2)
+
+	telegram := readiness.notifiers[0]
+	assert.Equal(t, "tgram", telegram.name)
+	assert.Equal(t, "ru", telegram.notifier.(*TelegramNotifier).catalog().Locale())
+	assert.Equal(t, "json", readiness.notifiers[1].name)
+
+	// --notifier-hosts applies to the upper case scheme
+	require.NoError(t, notifier.Notify(context.Background(), Event{Type: "container", Action: "die", Host: "db"}, false))
+	assert.Zero(t, requests.Load())
+	require.NoError(t, notifier.Notify(context.Background(), Event{Type: "container", Action: "die", Host: "web1"}, false))
+	assert.Equal(t, int32(1), requests.Load())
+}
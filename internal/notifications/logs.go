@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// logFile is a log tail sent as an attachment when it does not fit into a message
+// or the message doesn't show it
+type logFile struct {
+	Name    string
+	Content string
+}
+
+func hasLogs(events []Event) bool {
+	for _, e := range events {
+		if e.Logs != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutLogs(events []Event) []Event {
+	stripped := make([]Event, len(events))
+	for i, e := range events {
+		e.Logs = ""
+		stripped[i] = e
+	}
+	return stripped
+}
+
+// logsNotRendered are the events with logs that message leaves out, e.g. because a batch
+// template summarizes them. render is called again with the logs of one event removed,
+// the logs are missing when that doesn't change the message.
+func logsNotRendered(message string, events []Event, render func([]Event) string) []Event {
+	var missing []Event
+	for i, e := range events {
+		if e.Logs == "" {
+			continue
+		}
+		stripped := slices.Clone(events)
+		stripped[i].Logs = ""
+		if render(stripped) == message {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+func logFiles(events []Event) []logFile {
+	var files []logFile
+	for _, e := range events {
+		if e.Logs == "" {
+			continue
+		}
+		name := e.Name
+		if name == "" {
+			name = shortID(e.Container)
+		}
+		files = append(files, logFile{
+			Name:    fmt.Sprintf("%s-%s.log", name, time.Unix(e.Time, 0).UTC().Format("20060102-150405")),
+			Content: e.Logs + "\n",
+		})
+	}
+	return files
+}
+
+// tailRunes keeps the end of s, logs are most interesting at the bottom
+func tailRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return "…" + string(runes[len(runes)-limit+1:])
+}
+
+// htmlLineBreaks turns newlines into <br> outside of <pre> blocks
+func htmlLineBreaks(s string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "<pre>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "</pre>")
+		if end < 0 {
+			break
+		}
+		end += start + len("</pre>")
+		out.WriteString(strings.ReplaceAll(s[:start], "\n", "<br>\n"))
+		out.WriteString(s[start:end])
+		s = s[end:]
+	}
+	out.WriteString(strings.ReplaceAll(s, "\n", "<br>\n"))
+	return out.String()
+}
@@ -0,0 +1,175 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderLogs(t *testing.T) {
+	event := Event{Type: "container", Action: "die", Name: "api", ExitCode: "1", Logs: "starting\npanic: <nil> ```"}
+
+	assert.True(t, strings.HasSuffix(DefaultTemplates().Render(FormatText, event), "\nstarting\npanic: <nil> ```"))
+	assert.Contains(t, DefaultTemplates().Render(FormatMarkdown, event), "```\nstarting\npanic: <nil> '''\n```")
+	assert.Contains(t, DefaultTemplates().Render(FormatHTML, event), "<pre>starting\npanic: &lt;nil&gt; ```</pre>")
+}
+
+func TestHTMLLineBreaks(t *testing.T) {
+	assert.Equal(t,
+		"a<br>\nb<br>\n<pre>one\ntwo</pre><br>\nc",
+		htmlLineBreaks("a\nb\n<pre>one\ntwo</pre>\nc"),
+	)
+}
+
+func TestTailRunes(t *testing.T) {
+	assert.Equal(t, "short", tailRunes("short", 10))
+	assert.Equal(t, "…ёжз", tailRunes("абвгдеёжз", 4))
+}
+
+// recordingTransport answers every request with 200 and keeps the requests
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	r.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+}
+
+func multipartFiles(t *testing.T, req *http.Request, body string) map[string]string {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, _ := io.ReadAll(part)
+		name := part.FormName()
+		if part.FileName() != "" {
+			name = part.FileName()
+		}
+		files[name] = string(content)
+	}
+}
+
+func TestTelegramNotifier_LongLogsAsDocument(t *testing.T) {
+	transport := &recordingTransport{}
+	notifier := &TelegramNotifier{token: "dummy-token", chatID: "12345", client: &http.Client{Transport: transport}}
+
+	logs := strings.Repeat("line of output\n", 400) + "fatal error"
+	event := Event{Type: "container", Action: "die", Name: "api", ExitCode: "2", Time: 1700000000, Logs: logs}
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+
+	require.Len(t, transport.requests, 2)
+	assert.True(t, strings.HasSuffix(transport.requests[0].URL.Path, "/sendMessage"))
+	assert.NotContains(t, transport.bodies[0], "fatal+error")
+
+	assert.True(t, strings.HasSuffix(transport.requests[1].URL.Path, "/sendDocument"))
+	parts := multipartFiles(t, transport.requests[1], transport.bodies[1])
+	assert.Equal(t, "12345", parts["chat_id"])
+	assert.Equal(t, logs+"\n", parts["api-20231114-221320.log"])
+
+	// short logs stay inline
+	transport.requests, transport.bodies = nil, nil
+	event.Logs = "fatal error"
+	require.NoError(t, notifier.Notify(context.Background(), event, false))
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.bodies[0], "%3Cpre%3Efatal+error%3C%2Fpre%3E")
+}
+
+func TestDiscordNotifier_LongLogsAsFile(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewDiscordNotifier("1", "token")
+	notifier.webhookURL = server.URL
+
+	logs := strings.Repeat("x", 3000)
+	require.NoError(t, notifier.Notify(context.Background(), Event{Type: "container", Action: "die", Name: "api", Logs: logs}, false))
+
+	req := &http.Request{Header: http.Header{"Content-Type": {contentType}}}
+	parts := multipartFiles(t, req, body)
+	assert.Contains(t, parts["payload_json"], "`api`")
+	assert.NotContains(t, parts["payload_json"], "xxx")
+	assert.Equal(t, logs+"\n", parts["api-19700101-000000.log"])
+}
+
+func TestLogsAttachedWhenBatchTemplateOmitsThem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`{{len .Events}} events`), 0o644))
+
+	events := []Event{
+		{Type: "container", Action: "die", Name: "api", ExitCode: "1", Time: 1700000000, Logs: "fatal error"},
+		{Type: "container", Action: "start", Name: "web", Time: 1700000000},
+		{Type: "container", Action: "die", Name: "worker", ExitCode: "1", Time: 1700000000, Logs: "panic"},
+	}
+
+	t.Run("telegram", func(t *testing.T) {
+		templates, err := DefaultTemplates().WithBatchFile(FormatHTML, path)
+		require.NoError(t, err)
+		transport := &recordingTransport{}
+		notifier := &TelegramNotifier{token: "dummy-token", chatID: "12345", client: &http.Client{Transport: transport}}
+		notifier.SetTemplates(templates)
+
+		require.NoError(t, notifier.NotifyMultiple(context.Background(), events, false))
+		require.Len(t, transport.requests, 3)
+		assert.Contains(t, transport.bodies[0], "3+events")
+		assert.Equal(t, "fatal error\n", multipartFiles(t, transport.requests[1], transport.bodies[1])["api-20231114-221320.log"])
+		assert.Equal(t, "panic\n", multipartFiles(t, transport.requests[2], transport.bodies[2])["worker-20231114-221320.log"])
+
+		// the default batch template shows them inline
+		transport.requests, transport.bodies = nil, nil
+		notifier.SetTemplates(DefaultTemplates())
+		require.NoError(t, notifier.NotifyMultiple(context.Background(), events, false))
+		require.Len(t, transport.requests, 1)
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		templates, err := DefaultTemplates().WithBatchFile(FormatMarkdown, path)
+		require.NoError(t, err)
+		var contentType, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			contentType, body = r.Header.Get("Content-Type"), string(data)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		notifier := NewDiscordNotifier("1", "token")
+		notifier.webhookURL = server.URL
+		notifier.SetTemplates(templates)
+
+		require.NoError(t, notifier.NotifyMultiple(context.Background(), events, false))
+		parts := multipartFiles(t, &http.Request{Header: http.Header{"Content-Type": {contentType}}}, body)
+		assert.Contains(t, parts["payload_json"], "3 events")
+		assert.Equal(t, "fatal error\n", parts["api-20231114-221320.log"])
+		assert.Equal(t, "panic\n", parts["worker-20231114-221320.log"])
+	})
+}
@@ -2,8 +2,10 @@ package notifications
 
 import (
 	"context"
+	"errors"
 )
 
+// MultiNotifier sends to every notifier, one failing doesn't keep the event from the others
 type MultiNotifier struct {
 	notifiers []Notifier
 }
@@ -15,19 +17,21 @@ func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
 }
 
 func (m *MultiNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	var errs []error
 	for _, notifier := range m.notifiers {
 		if err := notifier.Notify(ctx, event, debug); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (m *MultiNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	var errs []error
 	for _, notifier := range m.notifiers {
 		if err := notifier.NotifyMultiple(ctx, events, debug); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,24 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiNotifier_FailureDoesNotBlockOthers(t *testing.T) {
+	ctx := context.Background()
+	recorder := &recordingNotifier{}
+	// e.g. the audit file on a full disk
+	notifier := NewMultiNotifier(&failingNotifier{err: errors.New("no space left on device")}, recorder)
+
+	err := notifier.Notify(ctx, Event{Name: "a"}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no space left on device")
+
+	require.Error(t, notifier.NotifyMultiple(ctx, []Event{{Name: "b"}, {Name: "c"}}, false))
+	assert.Len(t, recorder.events, 3)
+}
@@ -1,54 +1,368 @@
 package notifications
 
 import (
-	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types/events"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
 )
 
 type Event struct {
-	Type            string
-	Action          string
-	Container       string
-	Image           string
-	Time            int64
-	Status          string
-	Labels          map[string]string
-	Name            string
-	Project         string
-	Service         string
-	ExitCode        string
-	ExitCodeDetails string
-	ExecDuration    string
-
-	Message string
+	// Host is the name of the docker host when several are monitored
+	Host            string            `json:"host,omitempty"`
+	Type            string            `json:"type"`
+	Action          string            `json:"action"`
+	Container       string            `json:"container,omitempty"`
+	Image           string            `json:"image,omitempty"`
+	Time            int64             `json:"time,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Project         string            `json:"project,omitempty"`
+	Service         string            `json:"service,omitempty"`
+	ExitCode        string            `json:"exit_code,omitempty"`
+	ExitCodeDetails string            `json:"exit_code_details,omitempty"`
+	ExecDuration    string            `json:"exec_duration,omitempty"`
+	// Logs is the redacted tail of the container output for die and unhealthy events
+	Logs string `json:"logs,omitempty"`
+	// HealthLog holds the last healthcheck results of unhealthy events, oldest first
+	HealthLog           []HealthCheck `json:"health_log,omitempty"`
+	HealthFailingStreak int           `json:"health_failing_streak,omitempty"`
+	// Recovered is set on the first start or healthy event after a failure
+	Recovered bool `json:"recovered,omitempty"`
+	// Snapshot marks problems found at startup instead of by an event
+	Snapshot bool `json:"snapshot,omitempty"`
+
+	// filled from container inspect data
+	RestartPolicy string   `json:"restart_policy,omitempty"`
+	RestartCount  int      `json:"restart_count,omitempty"`
+	OOMKilled     bool     `json:"oom_killed,omitempty"`
+	MemoryLimit   int64    `json:"memory_limit,omitempty"`
+	StartedAt     int64    `json:"started_at,omitempty"`
+	FinishedAt    int64    `json:"finished_at,omitempty"`
+	ImageDigest   string   `json:"image_digest,omitempty"`
+	Hostname      string   `json:"hostname,omitempty"`
+	IPAddresses   []string `json:"ip_addresses,omitempty"`
+	Volumes       []string `json:"volumes,omitempty"`
+	WorkingDir    string   `json:"working_dir,omitempty"`
+
+	// set on image, volume and network events, Name is the image reference, volume or network name
+	Driver             string `json:"driver,omitempty"`
+	ConnectedContainer string `json:"connected_container,omitempty"`
+	ConnectedName      string `json:"connected_name,omitempty"`
+	Destination        string `json:"destination,omitempty"`
+
+	// set on swarm events, Name is the service, node, secret or config name
+	// and the service of task events
+	Changes          []Change `json:"changes,omitempty"`
+	Replicas         string   `json:"replicas,omitempty"`
+	UpdateState      string   `json:"update_state,omitempty"`
+	NodeState        string   `json:"node_state,omitempty"`
+	NodeAvailability string   `json:"node_availability,omitempty"`
+	Node             string   `json:"node,omitempty"`
+	TaskSlot         int      `json:"task_slot,omitempty"`
+	TaskError        string   `json:"task_error,omitempty"`
+
+	// set on daemon events, Name is the docker host
+	DaemonVersion     string `json:"daemon_version,omitempty"`
+	ContainersRunning int    `json:"containers_running,omitempty"`
+	DaemonError       string `json:"daemon_error,omitempty"`
+
+	// set on threshold events of the stats poller, e.g. Metric "cpu", Value 97.5, Threshold 90
+	Metric    string  `json:"metric,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// Disk is set on disk threshold events
+	Disk *DiskReport `json:"disk,omitempty"`
+
+	Message string `json:"message,omitempty"`
+	// ServerInfo is rendered in the notifier's locale instead of Message by the built-in templates
+	ServerInfo *ServerInfo `json:"server_info,omitempty"`
+}
+
+// Change is an attribute of a swarm object modified by an update, e.g. "replicas" 2 -> 3
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type HealthCheck struct {
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// ServerInfo is the report about a docker host sent at startup and on the summary schedule
+type ServerInfo struct {
+	Version         string `json:"version"`
+	Host            string `json:"host"`
+	OSType          string `json:"os_type"`
+	OperatingSystem string `json:"operating_system,omitempty"`
+	KernelVersion   string `json:"kernel_version,omitempty"`
+	Architecture    string `json:"architecture"`
+	CPUs            int    `json:"cpus"`
+	MemoryMB        int64  `json:"memory_mb"`
+
+	ContainersRunning int   `json:"containers_running"`
+	ContainersPaused  int   `json:"containers_paused"`
+	ContainersStopped int   `json:"containers_stopped"`
+	Images            int   `json:"images"`
+	DiskUsed          int64 `json:"disk_used,omitempty"`
+	DiskReclaimable   int64 `json:"disk_reclaimable,omitempty"`
+	// Uptime of the machine in seconds, only known for the local host
+	Uptime int64 `json:"uptime,omitempty"`
+	// names of unhealthy or restarting containers and of those that exited with an error
+	Unhealthy []string `json:"unhealthy,omitempty"`
+	Failed    []string `json:"failed,omitempty"`
+}
+
+// InfoLine is a label and its value in the server report
+type InfoLine struct {
+	Label string
+	Value string
+}
+
+// containers listed by name in the report, the rest are counted
+const maxListedContainers = 10
+
+// Lines are the report rows in the catalog's language, the templates format them
+func (i *ServerInfo) Lines(catalog *i18n.Catalog) []InfoLine {
+	lines := []InfoLine{
+		{catalog.T("info.version"), i.Version},
+		{catalog.T("info.host"), i.Host},
+		{catalog.T("info.type"), i.OSType},
+	}
+	if i.OperatingSystem != "" {
+		os := i.OperatingSystem
+		if i.KernelVersion != "" {
+			os += " (" + catalog.T("info.kernel", i.KernelVersion) + ")"
+		}
+		lines = append(lines, InfoLine{catalog.T("info.os"), os})
+	}
+	lines = append(lines,
+		InfoLine{catalog.T("info.architecture"), i.Architecture},
+		InfoLine{catalog.T("info.cpus"), strconv.Itoa(i.CPUs)},
+		InfoLine{catalog.T("info.memory"), catalog.T("info.megabytes", i.MemoryMB)},
+	)
+	if i.Uptime > 0 {
+		lines = append(lines, InfoLine{catalog.T("info.uptime"), catalog.Duration(time.Duration(i.Uptime) * time.Second)})
+	}
+
+	if i.ContainersRunning+i.ContainersPaused+i.ContainersStopped+i.Images == 0 {
+		return lines
+	}
+	lines = append(lines,
+		InfoLine{catalog.T("info.containers"), catalog.T("info.containers_value", i.ContainersRunning, i.ContainersPaused, i.ContainersStopped)},
+		InfoLine{catalog.T("info.images"), strconv.Itoa(i.Images)},
+	)
+	if i.DiskUsed > 0 {
+		lines = append(lines, InfoLine{catalog.T("info.disk"), catalog.T("info.disk_value", formatBytes(i.DiskUsed), formatBytes(i.DiskReclaimable))})
+	}
+	if len(i.Unhealthy) > 0 {
+		lines = append(lines, InfoLine{catalog.T("info.unhealthy"), listNames(i.Unhealthy)})
+	}
+	if len(i.Failed) > 0 {
+		lines = append(lines, InfoLine{catalog.T("info.failed"), listNames(i.Failed)})
+	}
+	if len(i.Unhealthy) == 0 && len(i.Failed) == 0 {
+		lines = append(lines, InfoLine{catalog.T("info.status"), catalog.T("info.all_healthy")})
+	}
+	return lines
+}
+
+func (i *ServerInfo) Text(catalog *i18n.Catalog) string {
+	var lines []string
+	for _, line := range i.Lines(catalog) {
+		lines = append(lines, line.Label+": "+line.Value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listNames joins names, "api, db (+3)" when there are too many
+func listNames(names []string) string {
+	if len(names) <= maxListedContainers {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s (+%d)", strings.Join(names[:maxListedContainers], ", "), len(names)-maxListedContainers)
+}
+
+// DiskReport describes the docker data root and what could be freed on it
+type DiskReport struct {
+	Path                  string     `json:"path"`
+	Total                 int64      `json:"total,omitempty"`
+	Free                  int64      `json:"free,omitempty"`
+	ImagesReclaimable     int64      `json:"images_reclaimable"`
+	BuildCacheReclaimable int64      `json:"build_cache_reclaimable"`
+	TopImages             []DiskItem `json:"top_images,omitempty"`
+	TopVolumes            []DiskItem `json:"top_volumes,omitempty"`
 }
 
-const textTpl = `{{if .Message}}{{.Message}}{{- else -}}
-{{.Type}} {{ActionName .Action}} {{.Name}} ({{.Image}})
-{{- if .ExecDuration}} (after {{Duration .ExecDuration}}){{- end -}}
+type DiskItem struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func (r *DiskReport) Text(catalog *i18n.Catalog) string {
+	var lines []string
+	if r.Total > 0 {
+		lines = append(lines, catalog.T("disk.free", formatBytes(r.Free), formatBytes(r.Total)))
+	}
+	lines = append(lines, catalog.T("disk.reclaimable", formatBytes(r.ImagesReclaimable), formatBytes(r.BuildCacheReclaimable)))
+	for _, list := range []struct {
+		key   string
+		items []DiskItem
+	}{{"disk.images", r.TopImages}, {"disk.volumes", r.TopVolumes}} {
+		if len(list.items) == 0 {
+			continue
+		}
+		items := make([]string, 0, len(list.items))
+		for _, item := range list.items {
+			items = append(items, item.Name+" "+formatBytes(item.Size))
+		}
+		lines = append(lines, catalog.T(list.key)+": "+strings.Join(items, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+const textTpl = `{{with .Host}}[{{.}}] {{end}}
+{{- if .ServerInfo}}{{template "server_info" .}}
+{{- else if .Message}}{{.Message}}
+{{- else if .Disk}}{{template "disk" .}}
+{{- else if .IsResource}}{{template "resource" .}}
+{{- else if .IsSwarm}}{{template "swarm" .}}
+{{- else if eq .Type "daemon"}}{{template "daemon" .}}
+{{- else}}{{template "container" .}}{{end -}}
+
+{{define "server_info"}}{{if eq .Type "summary"}}{{T "report.title"}}{{"\n"}}{{end}}
+{{- range $i, $l := InfoLines .ServerInfo}}{{if $i}}{{"\n"}}{{end}}{{$l.Label}}: {{$l.Value}}{{end}}{{end -}}
+
+{{define "disk"}}{{.Type}} {{ActionName .Action}} {{.Disk.Path}} {{T (print "metric." .Metric)}}: {{MetricValue .Metric .Value}} ({{T "template.threshold"}} {{MetricValue .Metric .Threshold}})
+{{DiskReport .Disk}}{{end -}}
+
+{{define "resource"}}{{.Type}} {{ActionName .Action}} {{.Name}}
+{{- with .ImageDigest}} ({{ShortDigest .}}){{end}}
+{{- with .Driver}} ({{T "template.driver"}} {{.}}){{end}}
+{{- if .ConnectedContainer}} {{T "template.container"}} {{or .ConnectedName (ShortID .ConnectedContainer)}}{{end}}
+{{- with .Destination}}:{{.}}{{end}}{{end -}}
+
+{{define "swarm"}}{{.Type}} {{ActionName .Action}} {{.Name}}
+{{- if .TaskSlot}}.{{.TaskSlot}}{{end}}
+{{- with .Node}} ({{T "template.node"}} {{.}}){{end}}
+{{- range .Changes}} {{T (print "change." .Field)}}: {{.Old}} → {{.New}}{{end}}
+{{- with .ExitCode}} {{T "template.exit_code"}}: {{.}}{{end}}
+{{- with .TaskError}} "{{.}}"{{end}}{{end -}}
+
+{{define "daemon"}}{{if eq .Action "reachable"}}{{T "daemon.reachable" .Name .DaemonVersion .ContainersRunning}}
+{{- else if eq .Action "unreachable"}}{{T "daemon.unreachable" .Name}}{{with .DaemonError}}: {{.}}{{end}}
+{{- else}}{{T (print "daemon." .Action) .Name}}{{end}}{{end -}}
+
+{{define "container"}}{{.Type}} {{if .OOMKilled}}{{T "action.oom"}}{{with .MemoryLimit}} ({{T "template.memory_limit" (Bytes .)}}){{end}}{{else}}{{ActionName .Action}}{{end}} {{.Name}} ({{.Image}})
+{{- if .Recovered}} ({{T "template.recovered"}}){{end}}{{if .Snapshot}} ({{T "template.at_startup"}}){{end}}
+{{- if .ExecDuration}} ({{T "template.after"}} {{Duration .ExecDuration}}){{- end -}}
 {{- if and .Project .Service }} {{.Project}}::{{.Service}}{{- end}}
-{{- if .ExitCode }} Exit code: {{.ExitCode}}{{if .ExitCodeDetails}} "{{.ExitCodeDetails}}"{{end}}{{- end}}{{end -}}
+{{- with .Metric}} {{T (print "metric." .)}}: {{printf "%.1f" $.Value}} ({{T "template.threshold"}} {{printf "%g" $.Threshold}}){{end}}
+{{- if .ExitCode }} {{T "template.exit_code"}}: {{.ExitCode}}{{with or (ExitCodeDetails .ExitCode) .ExitCodeDetails}} "{{.}}"{{end}}{{- end}}
+{{- with .HealthLog}}
+{{T "template.failing_streak" $.HealthFailingStreak}}{{range .}}
+{{FormatTime .End "15:04:05"}} {{T "template.exit"}} {{.ExitCode}}: {{.Output}}{{end}}{{end}}
+{{- with .Logs}}
+{{.}}{{end}}{{end -}}
 `
 
-const mdTpl = `{{if .Message}}{{EscapeMarkdown .Message}}{{- else -}}
-{{.Type}} *{{ActionName .Action}}* {{WrapCode .Name}} ({{WrapCode .Image}})
-{{- if .ExecDuration}} (after {{Duration .ExecDuration}}){{- end -}}
+const mdTpl = `{{with .Host}}*[{{EscapeMarkdown .}}]* {{end}}
+{{- if .ServerInfo}}{{template "server_info" .}}
+{{- else if .Message}}{{EscapeMarkdown .Message}}
+{{- else if .Disk}}{{template "disk" .}}
+{{- else if .IsResource}}{{template "resource" .}}
+{{- else if .IsSwarm}}{{template "swarm" .}}
+{{- else if eq .Type "daemon"}}{{template "daemon" .}}
+{{- else}}{{template "container" .}}{{end -}}
+
+{{define "server_info"}}{{if eq .Type "summary"}}*{{T "report.title"}}*{{"\n"}}{{end}}
+{{- range $i, $l := InfoLines .ServerInfo}}{{if $i}}{{"\n"}}{{end}}*{{EscapeMarkdown $l.Label}}:* {{EscapeMarkdown $l.Value}}{{end}}{{end -}}
+
+{{define "disk"}}{{.Type}} *{{ActionName .Action}}* {{WrapCode .Disk.Path}} {{T (print "metric." .Metric)}}: *{{MetricValue .Metric .Value}}* ({{T "template.threshold"}} {{MetricValue .Metric .Threshold}})
+{{EscapeMarkdown (DiskReport .Disk)}}{{end -}}
+
+{{define "resource"}}{{.Type}} *{{ActionName .Action}}* {{WrapCode .Name}}
+{{- with .ImageDigest}} ({{WrapCode (ShortDigest .)}}){{end}}
+{{- with .Driver}} ({{T "template.driver"}} {{WrapCode .}}){{end}}
+{{- if .ConnectedContainer}} {{T "template.container"}} {{WrapCode (or .ConnectedName (ShortID .ConnectedContainer))}}{{end}}
+{{- with .Destination}}:{{WrapCode .}}{{end}}{{end -}}
+
+{{define "swarm"}}{{.Type}} *{{ActionName .Action}}* {{if .TaskSlot}}{{WrapCode (printf "%s.%d" .Name .TaskSlot)}}{{else}}{{WrapCode .Name}}{{end}}
+{{- with .Node}} ({{T "template.node"}} {{WrapCode .}}){{end}}
+{{- range .Changes}} {{T (print "change." .Field)}}: {{WrapCode .Old}} → {{WrapCode .New}}{{end}}
+{{- with .ExitCode}} {{T "template.exit_code"}}: {{WrapCode .}}{{end}}
+{{- with .TaskError}} "_{{EscapeMarkdown .}}_"{{end}}{{end -}}
+
+{{define "daemon"}}{{if eq .Action "reachable"}}*{{T "daemon.reachable" .Name .DaemonVersion .ContainersRunning}}*
+{{- else if eq .Action "unreachable"}}*{{T "daemon.unreachable" .Name}}*{{with .DaemonError}}: {{WrapCode .}}{{end}}
+{{- else}}*{{T (print "daemon." .Action) .Name}}*{{end}}{{end -}}
+
+{{define "container"}}{{.Type}} {{if .OOMKilled}}*{{T "action.oom"}}*{{with .MemoryLimit}} ({{T "template.memory_limit" (Bytes .)}}){{end}}{{else}}*{{ActionName .Action}}*{{end}} {{WrapCode .Name}} ({{WrapCode .Image}})
+{{- if .Recovered}} _{{T "template.recovered"}}_{{end}}{{if .Snapshot}} _{{T "template.at_startup"}}_{{end}}
+{{- if .ExecDuration}} ({{T "template.after"}} {{Duration .ExecDuration}}){{- end -}}
 {{- if and .Project .Service }} {{WrapCode .Project}}::{{WrapCode .Service}}{{- end}}
+{{- with .Metric}} {{T (print "metric." .)}}: *{{printf "%.1f" $.Value}}* ({{T "template.threshold"}} {{printf "%g" $.Threshold}}){{end}}
 {{if .ExitCode
--}}Exit code: {{WrapCode .ExitCode}}{{if .ExitCodeDetails}} "_{{.ExitCodeDetails}}_"{{end}}{{-
-end}}{{end -}}
+-}}{{T "template.exit_code"}}: {{WrapCode .ExitCode}}{{with or (ExitCodeDetails .ExitCode) .ExitCodeDetails}} "_{{.}}_"{{end}}{{-
+end}}{{with .HealthLog}}
+*{{T "template.failing_streak" $.HealthFailingStreak}}*{{range .}}
+{{FormatTime .End "15:04:05"}} {{T "template.exit"}} {{WrapCode (print .ExitCode)}}: {{WrapCode .Output}}{{end}}{{end}}{{with .Logs}}
+{{CodeBlock .}}{{end}}{{end -}}
 `
 
-const htmlTpl = `{{if .Message}}{{.Message}}{{- else -}}
-{{.Type}} <b>{{ActionName .Action}}</b> <code>{{EscapeHTML .Name}}</code> (<code>{{EscapeHTML .Image}}</code>)
-{{- if .ExecDuration}} (after <u>{{Duration .ExecDuration}}</u>){{- end -}}
+const htmlTpl = `{{with .Host}}<b>[{{EscapeHTML .}}]</b> {{end}}
+{{- if .ServerInfo}}{{template "server_info" .}}
+{{- else if .Message}}{{.Message}}
+{{- else if .Disk}}{{template "disk" .}}
+{{- else if .IsResource}}{{template "resource" .}}
+{{- else if .IsSwarm}}{{template "swarm" .}}
+{{- else if eq .Type "daemon"}}{{template "daemon" .}}
+{{- else}}{{template "container" .}}{{end -}}
+
+{{define "server_info"}}{{if eq .Type "summary"}}<b>{{T "report.title"}}</b>{{"\n"}}{{end}}
+{{- range $i, $l := InfoLines .ServerInfo}}{{if $i}}{{"\n"}}{{end}}<b>{{EscapeHTML $l.Label}}:</b> {{EscapeHTML $l.Value}}{{end}}{{end -}}
+
+{{define "disk"}}{{.Type}} <b>{{ActionName .Action}}</b> <code>{{EscapeHTML .Disk.Path}}</code> {{T (print "metric." .Metric)}}: <b>{{MetricValue .Metric .Value}}</b> ({{T "template.threshold"}} {{MetricValue .Metric .Threshold}})
+{{EscapeHTML (DiskReport .Disk)}}{{end -}}
+
+{{define "resource"}}{{.Type}} <b>{{ActionName .Action}}</b> <code>{{EscapeHTML .Name}}</code>
+{{- with .ImageDigest}} (<code>{{ShortDigest .}}</code>){{end}}
+{{- with .Driver}} ({{T "template.driver"}} <code>{{EscapeHTML .}}</code>){{end}}
+{{- if .ConnectedContainer}} {{T "template.container"}} <code>{{EscapeHTML (or .ConnectedName (ShortID .ConnectedContainer))}}</code>{{end}}
+{{- with .Destination}}:<code>{{EscapeHTML .}}</code>{{end}}{{end -}}
+
+{{define "swarm"}}{{.Type}} <b>{{ActionName .Action}}</b> <code>{{EscapeHTML .Name}}{{if .TaskSlot}}.{{.TaskSlot}}{{end}}</code>
+{{- with .Node}} ({{T "template.node"}} <code>{{EscapeHTML .}}</code>){{end}}
+{{- range .Changes}} {{T (print "change." .Field)}}: <code>{{EscapeHTML .Old}}</code> → <code>{{EscapeHTML .New}}</code>{{end}}
+{{- with .ExitCode}} {{T "template.exit_code"}}: <code>{{.}}</code>{{end}}
+{{- with .TaskError}} "<i>{{EscapeHTML .}}</i>"{{end}}{{end -}}
+
+{{define "daemon"}}{{if eq .Action "reachable"}}<b>{{EscapeHTML (T "daemon.reachable" .Name .DaemonVersion .ContainersRunning)}}</b>
+{{- else if eq .Action "unreachable"}}<b>{{EscapeHTML (T "daemon.unreachable" .Name)}}</b>{{with .DaemonError}}: <code>{{EscapeHTML .}}</code>{{end}}
+{{- else}}<b>{{EscapeHTML (T (print "daemon." .Action) .Name)}}</b>{{end}}{{end -}}
+
+{{define "container"}}{{.Type}} {{if .OOMKilled}}<b>{{T "action.oom"}}</b>{{with .MemoryLimit}} ({{T "template.memory_limit" (Bytes .)}}){{end}}{{else}}<b>{{ActionName .Action}}</b>{{end}} <code>{{EscapeHTML .Name}}</code> (<code>{{EscapeHTML .Image}}</code>)
+{{- if .Recovered}} <i>{{T "template.recovered"}}</i>{{end}}{{if .Snapshot}} <i>{{T "template.at_startup"}}</i>{{end}}
+{{- if .ExecDuration}} ({{T "template.after"}} <u>{{Duration .ExecDuration}}</u>){{- end -}}
 {{- if and .Project .Service }} <code>{{EscapeHTML .Project}}</code>::<code>{{EscapeHTML .Service}}</code>{{- end}}
-{{- if .ExitCode}} Exit code: <code>{{.ExitCode}}</code>{{if .ExitCodeDetails}} "<i>{{EscapeHTML .ExitCodeDetails}}</i>"{{end}}{{- end}}{{end -}}
+{{- with .Metric}} {{T (print "metric." .)}}: <b>{{printf "%.1f" $.Value}}</b> ({{T "template.threshold"}} {{printf "%g" $.Threshold}}){{end}}
+{{- if .ExitCode}} {{T "template.exit_code"}}: <code>{{.ExitCode}}</code>{{with or (ExitCodeDetails .ExitCode) .ExitCodeDetails}} "<i>{{EscapeHTML .}}</i>"{{end}}{{- end}}
+{{- with .HealthLog}}
+<b>{{T "template.failing_streak" $.HealthFailingStreak}}</b>{{range .}}
+{{FormatTime .End "15:04:05"}} {{T "template.exit"}} <code>{{.ExitCode}}</code>: <code>{{EscapeHTML .Output}}</code>{{end}}{{end}}
+{{- with .Logs}}
+<pre>{{EscapeHTML .}}</pre>{{end}}{{end -}}
 `
 
 var Reset = "\033[0m"
@@ -61,87 +375,53 @@ var Cyan = "\033[36m"
 var Gray = "\033[37m"
 var White = "\033[97m"
 
-const ansiTpl = `{{if .Message}}{{.Message}}{{- else -}}
-{{.Type}} {{Yellow}}{{ActionName .Action}}{{Reset}} {{Cyan}}{{.Name}}{{Reset}} {{Green}}({{.Image}}){{Reset}}
-{{- if .ExecDuration}} (after {{White}}{{Duration .ExecDuration}}{{Reset}}){{- end -}}
+const ansiTpl = `{{with .Host}}{{Magenta}}[{{.}}]{{Reset}} {{end}}
+{{- if .ServerInfo}}{{template "server_info" .}}
+{{- else if .Message}}{{.Message}}
+{{- else if .Disk}}{{template "disk" .}}
+{{- else if .IsResource}}{{template "resource" .}}
+{{- else if .IsSwarm}}{{template "swarm" .}}
+{{- else if eq .Type "daemon"}}{{template "daemon" .}}
+{{- else}}{{template "container" .}}{{end -}}
+
+{{define "server_info"}}{{if eq .Type "summary"}}{{Yellow}}{{T "report.title"}}{{Reset}}{{"\n"}}{{end}}
+{{- range $i, $l := InfoLines .ServerInfo}}{{if $i}}{{"\n"}}{{end}}{{Cyan}}{{$l.Label}}:{{Reset}} {{$l.Value}}{{end}}{{end -}}
+
+{{define "disk"}}{{.Type}} {{Yellow}}{{ActionName .Action}}{{Reset}} {{Cyan}}{{.Disk.Path}}{{Reset}} {{T (print "metric." .Metric)}}: {{Red}}{{MetricValue .Metric .Value}}{{Reset}} ({{T "template.threshold"}} {{MetricValue .Metric .Threshold}})
+{{DiskReport .Disk}}{{end -}}
+
+{{define "resource"}}{{.Type}} {{Yellow}}{{ActionName .Action}}{{Reset}} {{Cyan}}{{.Name}}{{Reset}}
+{{- with .ImageDigest}} {{Gray}}({{ShortDigest .}}){{Reset}}{{end}}
+{{- with .Driver}} ({{T "template.driver"}} {{.}}){{end}}
+{{- if .ConnectedContainer}} {{T "template.container"}} {{Cyan}}{{or .ConnectedName (ShortID .ConnectedContainer)}}{{Reset}}{{end}}
+{{- with .Destination}}:{{.}}{{end}}{{end -}}
+
+{{define "swarm"}}{{.Type}} {{Yellow}}{{ActionName .Action}}{{Reset}} {{Cyan}}{{.Name}}{{if .TaskSlot}}.{{.TaskSlot}}{{end}}{{Reset}}
+{{- with .Node}} ({{T "template.node"}} {{Blue}}{{.}}{{Reset}}){{end}}
+{{- range .Changes}} {{T (print "change." .Field)}}: {{Gray}}{{.Old}}{{Reset}} → {{White}}{{.New}}{{Reset}}{{end}}
+{{- with .ExitCode}} {{T "template.exit_code"}}: {{Red}}{{.}}{{Reset}}{{end}}
+{{- with .TaskError}} "{{.}}"{{end}}{{end -}}
+
+{{define "daemon"}}{{if eq .Action "reachable"}}{{Green}}{{T "daemon.reachable" .Name .DaemonVersion .ContainersRunning}}{{Reset}}
+{{- else if eq .Action "unreachable"}}{{Red}}{{T "daemon.unreachable" .Name}}{{Reset}}{{with .DaemonError}}: {{.}}{{end}}
+{{- else}}{{Yellow}}{{T (print "daemon." .Action) .Name}}{{Reset}}{{end}}{{end -}}
+
+{{define "container"}}{{.Type}} {{if .OOMKilled}}{{Red}}{{T "action.oom"}}{{Reset}}{{with .MemoryLimit}} ({{T "template.memory_limit" (Bytes .)}}){{end}}{{else}}{{Yellow}}{{ActionName .Action}}{{Reset}}{{end}} {{Cyan}}{{.Name}}{{Reset}} {{Green}}({{.Image}}){{Reset}}
+{{- if .Recovered}} {{Green}}{{T "template.recovered"}}{{Reset}}{{end}}{{if .Snapshot}} ({{T "template.at_startup"}}){{end}}
+{{- if .ExecDuration}} ({{T "template.after"}} {{White}}{{Duration .ExecDuration}}{{Reset}}){{- end -}}
 {{- if and .Project .Service }} {{Blue}}{{.Project}}{{Reset}}::{{Magenta}}{{.Service}}{{Reset}}{{- end -}}
+{{- with .Metric}} {{T (print "metric." .)}}: {{Red}}{{printf "%.1f" $.Value}}{{Reset}} ({{T "template.threshold"}} {{printf "%g" $.Threshold}}){{end -}}
 {{if .ExitCode
-}} Exit code: {{if eq .ExitCode "0"}}{{Green}}{{.ExitCode}}{{Reset}}{{else}}{{Red}}{{.ExitCode}}{{Reset}}{{end
--}}{{if .ExitCodeDetails}} "{{.ExitCodeDetails}}"{{end}}{{- end}}{{end -}}
+}} {{T "template.exit_code"}}: {{if eq .ExitCode "0"}}{{Green}}{{.ExitCode}}{{Reset}}{{else}}{{Red}}{{.ExitCode}}{{Reset}}{{end
+-}}{{with or (ExitCodeDetails .ExitCode) .ExitCodeDetails}} "{{.}}"{{end}}{{- end}}
+{{- with .HealthLog}}
+{{Yellow}}{{T "template.failing_streak" $.HealthFailingStreak}}{{Reset}}{{range .}}
+{{FormatTime .End "15:04:05"}} {{T "template.exit"}} {{Red}}{{.ExitCode}}{{Reset}}: {{.Output}}{{end}}{{end}}
+{{- with .Logs}}
+{{Gray}}{{.}}{{Reset}}{{end}}{{end -}}
 `
 
-var (
-	textTemplate *template.Template
-	mdTemplate   *template.Template
-	ansiTemplate *template.Template
-	htmlTemplate *template.Template
-)
-
-func init() {
-	// Initialize templates during package initialization
-	var err error
-
-	funcMap := template.FuncMap{
-		"ShortID": func(s string) string { return s[0:20] },
-		"WrapCode": func(s string) string {
-			return "`" + strings.ReplaceAll(s, "`", "'") + "`"
-		},
-		"ActionName": func(s string) string {
-			if s == "die" {
-				return "stop"
-			}
-			if s == "health_status: healthy" {
-				return "healthy"
-			}
-			if s == "health_status: unhealthy" {
-				return "unhealthy"
-			}
-			return s
-		},
-		"Duration": func(s string) string {
-			duration, err := time.ParseDuration(s + "s")
-			if err == nil {
-				return duration.String()
-			}
-			return s + "s"
-		},
-		// ansi colors
-		"Red":            func() string { return Red },
-		"Green":          func() string { return Green },
-		"Yellow":         func() string { return Yellow },
-		"Blue":           func() string { return Blue },
-		"Magenta":        func() string { return Magenta },
-		"Cyan":           func() string { return Cyan },
-		"Gray":           func() string { return Gray },
-		"White":          func() string { return White },
-		"Reset":          func() string { return Reset },
-		"EscapeHTML":     EscapeHTMLTelegram,
-		"EscapeMarkdown": EscapeMarkdownReservedChars,
-	}
-
-	textTemplate, err = template.New("text").Funcs(funcMap).Parse(textTpl)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to parse text template: %v", err))
-	}
-
-	mdTemplate, err = template.New("md").Funcs(funcMap).Parse(mdTpl)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to parse markdown template: %v", err))
-	}
-
-	ansiTemplate, err = template.New("ansi").Funcs(funcMap).Parse(ansiTpl)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to parse ANSI template: %v", err))
-	}
-
-	htmlTemplate, err = template.New("html").Funcs(funcMap).Parse(htmlTpl)
-	if err != nil {
-		panic(fmt.Sprintf("Failed to parse HTML template: %v", err))
-	}
-}
-
 type EventActionMap map[string]map[string]bool
-type ExitCodeMap map[string]string
 
 const containerNameLabel = "name"
 const dockerComposeProjectLabel = "com.docker.compose.project"
@@ -149,36 +429,119 @@ const dockerComposeServiceLabel = "com.docker.compose.service"
 const execDurationLabel = "execDuration"
 const exitCodeLabel = "exitCode"
 
+// oomKilledLabel marks die events of runtimes without an enricher, like containerd, that followed an OOM kill
+const oomKilledLabel = "oomKilled"
+const swarmStackLabel = "com.docker.stack.namespace"
+const swarmServiceLabel = "com.docker.swarm.service.name"
+
 var SupportedEvents = EventActionMap{
 	"container": {
 		"start":                    true,
 		"die":                      true,
 		"health_status: healthy":   true,
 		"health_status: unhealthy": true,
+		"threshold_exceeded":       true,
+		"threshold_resolved":       true,
 	},
 	"connection": {
 		"message": true,
 	},
+	"daemon": {
+		"reload": true,
+		// from the daemon watcher
+		"unreachable": true,
+		"reachable":   true,
+	},
+	// only emitted on swarm managers
+	"service": {
+		"create": true,
+		"update": true,
+		"remove": true,
+	},
+	"node": {
+		"create": true,
+		"update": true,
+		"remove": true,
+	},
+	// from the task watcher
+	"task": {
+		"failed":   true,
+		"rejected": true,
+	},
 }
 
-var exitCodeMapping = ExitCodeMap{
-	"0": "Success",
-	// https://tldp.org/LDP/abs/html/exitcodes.html
-	"1": "Application error",
-	"2": "Misuse of builtin",
-	// https://docs.docker.com/engine/containers/run/#exit-status
-	"125": "Container failed to run",
-	"126": "Container command cannot be invoked",
-	"127": "Container command cannot be found",
-	"128": "Invalid argument used on exit",
-
-	// 128 + n Fatal
-	"134": "Abnormal termination SIGABRT",
-	"137": "Immediate termination SIGKILL",
-	"139": "Segmentation Fault SIGSEGV",
-	"143": "Graceful termination SIGTERM",
-
-	"255": "Exit status out of range",
+// OptionalEvents are parsed and rendered but only notified once enabled with EnableEvents
+var OptionalEvents = EventActionMap{
+	"image": {
+		"pull":   true,
+		"delete": true,
+		"tag":    true,
+		"untag":  true,
+	},
+	"volume": {
+		"create":  true,
+		"destroy": true,
+		"mount":   true,
+	},
+	"network": {
+		"create":  true,
+		"destroy": true,
+		// swarm scoped networks
+		"remove":     true,
+		"connect":    true,
+		"disconnect": true,
+	},
+	// Podman only
+	"pod": {
+		"create": true,
+		"start":  true,
+		"stop":   true,
+		"kill":   true,
+		"remove": true,
+	},
+	"secret": {
+		"create": true,
+		"update": true,
+		"remove": true,
+	},
+	"config": {
+		"create": true,
+		"update": true,
+		"remove": true,
+	},
+}
+
+// EnableEvents adds optional events to SupportedEvents,
+// e.g. "image:delete" for a single action or "network" for all of them
+func EnableEvents(specs []string) error {
+	for _, spec := range specs {
+		eventType, action, _ := strings.Cut(spec, ":")
+		actions, ok := OptionalEvents[eventType]
+		if !ok || (action != "" && !actions[action]) {
+			return fmt.Errorf("unknown event %q, available: %s", spec, strings.Join(optionalEventNames(), ", "))
+		}
+
+		if SupportedEvents[eventType] == nil {
+			SupportedEvents[eventType] = map[string]bool{}
+		}
+		for name := range actions {
+			if action == "" || action == name {
+				SupportedEvents[eventType][name] = true
+			}
+		}
+	}
+	return nil
+}
+
+func optionalEventNames() []string {
+	var names []string
+	for eventType, actions := range OptionalEvents {
+		for action := range actions {
+			names = append(names, eventType+":"+action)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 func NewEventFromDocker(msg events.Message) Event {
@@ -190,7 +553,7 @@ func NewEventFromDocker(msg events.Message) Event {
 	exitCode, _ := labels[exitCodeLabel]
 	execDuration, _ := labels[execDurationLabel]
 
-	return Event{
+	evt := Event{
 		Type:      string(msg.Type),
 		Action:    string(msg.Action),
 		Container: msg.Actor.ID,
@@ -205,7 +568,102 @@ func NewEventFromDocker(msg events.Message) Event {
 		ExitCode:        exitCode,
 		ExitCodeDetails: getExitCodeDetails(exitCode),
 		ExecDuration:    execDuration,
+		OOMKilled:       labels[oomKilledLabel] == "true",
+	}
+
+	switch msg.Type {
+	case events.ContainerEventType:
+		// containers of swarm tasks
+		if evt.Project == "" {
+			evt.Project = labels[swarmStackLabel]
+		}
+		if evt.Service == "" {
+			evt.Service = labels[swarmServiceLabel]
+		}
+	case events.DaemonEventType:
+		evt.Container = ""
+	case events.ServiceEventType, events.NodeEventType, events.SecretEventType, events.ConfigEventType:
+		evt.Container = ""
+		evt.Changes = changes(labels)
+		evt.Replicas = labels["replicas.new"]
+		evt.UpdateState = labels["updatestate.new"]
+		evt.NodeState = labels["state.new"]
+		evt.NodeAvailability = labels["availability.new"]
+		if msg.Type == events.ServiceEventType {
+			evt.Service = name
+			evt.Image = labels["image.new"]
+		}
+	case events.ImageEventType:
+		evt.Container = ""
+		// pull events carry the reference, the others the image ID and a tag or the ID in "name"
+		if strings.HasPrefix(msg.Actor.ID, "sha256:") {
+			evt.ImageDigest = msg.Actor.ID
+		} else {
+			evt.Name = msg.Actor.ID
+		}
+		if evt.Name == evt.ImageDigest {
+			evt.Name = shortDigest(evt.ImageDigest)
+			evt.ImageDigest = ""
+		}
+		evt.Image = evt.Name
+	case events.VolumeEventType:
+		evt.Container = ""
+		evt.Name = msg.Actor.ID
+		evt.Driver = labels["driver"]
+		evt.ConnectedContainer = labels["container"]
+		evt.Destination = labels["destination"]
+	case "pod":
+		evt.Container = ""
+		evt.Image = ""
+	case events.NetworkEventType:
+		evt.Container = ""
+		evt.Driver = labels["type"]
+		evt.ConnectedContainer = labels["container"]
+	}
+
+	return evt
+}
+
+// changes collects the "<field>.old" and "<field>.new" attributes of swarm update events
+func changes(attributes map[string]string) []Change {
+	var result []Change
+	for key, value := range attributes {
+		field, ok := strings.CutSuffix(key, ".new")
+		if !ok {
+			continue
+		}
+		result = append(result, Change{Field: field, Old: attributes[field+".old"], New: value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Field < result[j].Field
+	})
+	return result
+}
+
+// IsSwarm is true for service, node, secret, config and task events
+func (e Event) IsSwarm() bool {
+	switch e.Type {
+	case "service", "node", "secret", "config", "task":
+		return true
+	}
+	return false
+}
+
+// IsResource is true for image, volume, network and pod events
+func (e Event) IsResource() bool {
+	switch e.Type {
+	case "image", "volume", "network", "pod":
+		return true
 	}
+	return false
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
 }
 
 func (e Event) ShouldNotify(debug bool) bool {
@@ -220,11 +678,80 @@ func (e Event) ShouldNotify(debug bool) bool {
 		return false
 	}
 
+	// swarm objects are updated all the time without any visible change, e.g. node heartbeats
+	if (e.Type == "service" || e.Type == "node") && e.Action == "update" && len(e.Changes) == 0 {
+		if debug {
+			fmt.Printf("Skipping %s update without changes: %s\n", e.Type, e.Name)
+		}
+		return false
+	}
+
 	return true
 }
 
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+	SeverityOK       Severity = "ok"
+)
+
+func (e Event) Severity() Severity {
+	switch e.Type {
+	case "daemon":
+		switch e.Action {
+		case "unreachable":
+			return SeverityCritical
+		case "reachable":
+			return SeverityOK
+		}
+		return SeverityInfo
+	case "task":
+		return SeverityCritical
+	case "node":
+		switch {
+		case e.Action == "remove":
+			return SeverityWarning
+		case e.NodeState == "down" || e.NodeState == "disconnected" || e.NodeState == "unknown":
+			return SeverityCritical
+		case e.NodeAvailability == "drain" || e.NodeAvailability == "pause":
+			return SeverityWarning
+		case e.NodeState == "ready" || e.NodeAvailability == "active":
+			return SeverityOK
+		}
+		return SeverityInfo
+	case "service":
+		switch e.UpdateState {
+		case "paused", "rollback_paused":
+			return SeverityCritical
+		case "rollback_started":
+			return SeverityWarning
+		case "completed", "rollback_completed":
+			return SeverityOK
+		}
+		return SeverityInfo
+	}
+
+	switch e.Action {
+	case "die":
+		if e.ExitCode == "" || e.ExitCode == "0" {
+			return SeverityWarning
+		}
+		return SeverityCritical
+	case "health_status: unhealthy", "restarting":
+		return SeverityCritical
+	case "threshold_exceeded":
+		return SeverityWarning
+	case "start", "health_status: healthy", "threshold_resolved":
+		return SeverityOK
+	}
+	return SeverityInfo
+}
+
 func getExitCodeDetails(exitCode string) string {
-	if msg, ok := exitCodeMapping[exitCode]; ok {
+	if msg, ok := i18n.English().Lookup("exit_code." + exitCode); ok {
 		return msg
 	}
 
@@ -232,15 +759,7 @@ func getExitCodeDetails(exitCode string) string {
 }
 
 func (e *Event) Text() string {
-	var buf bytes.Buffer
-	err := textTemplate.Execute(&buf, e)
-	if err != nil {
-		fmt.Printf("Error generating template: %v\n", err)
-		// cheap fallback
-		return e.Type + " " + e.Action + " " + e.Name
-	}
-
-	return buf.String()
+	return DefaultTemplates().Render(FormatText, *e)
 }
 
 func EscapeMarkdownReservedChars(text string) string {
@@ -264,35 +783,13 @@ func EscapeHTMLTelegram(text string) string {
 }
 
 func (e *Event) Markdown() string {
-	var buf bytes.Buffer
-	err := mdTemplate.Execute(&buf, e)
-	if err != nil {
-		fmt.Printf("Error generating template: %v\n", err)
-		// cheap fallback
-		return e.Type + " " + e.Action + " " + e.Name
-	}
-
-	return buf.String()
+	return DefaultTemplates().Render(FormatMarkdown, *e)
 }
 
 func (e *Event) ANSI() string {
-	var buf bytes.Buffer
-	err := ansiTemplate.Execute(&buf, e)
-	if err != nil {
-		fmt.Printf("Error generating template: %v\n", err)
-		// cheap fallback
-		return e.Type + " " + e.Action + " " + e.Name
-	}
-
-	return buf.String()
+	return DefaultTemplates().Render(FormatANSI, *e)
 }
 
 func (e *Event) HTML() string {
-	var buf bytes.Buffer
-	err := htmlTemplate.Execute(&buf, e)
-	if err != nil {
-		fmt.Printf("Error generating HTML template: %v\n", err)
-		return e.Type + " " + e.Action + " " + e.Name
-	}
-	return buf.String()
+	return DefaultTemplates().Render(FormatHTML, *e)
 }
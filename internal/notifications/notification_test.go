@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"maps"
 	"strings"
 	"testing"
 	"time"
@@ -247,3 +248,371 @@ func TestActionNameMapping(t *testing.T) {
 		t.Errorf("Expected health action to be mapped to 'healthy', but got: %s", result)
 	}
 }
+
+func TestEventHealthLog(t *testing.T) {
+	event := Event{
+		Type:                "container",
+		Action:              "health_status: unhealthy",
+		Name:                "api",
+		HealthFailingStreak: 3,
+		HealthLog: []HealthCheck{
+			{Start: 1700000000, End: 1700000001, ExitCode: 1, Output: "curl: (7) <refused>"},
+			{Start: 1700000030, End: 1700000031, ExitCode: 1, Output: "timeout"},
+		},
+	}
+
+	for format, parts := range map[Format][]string{
+		FormatText:     {"Failing health checks: 3", "exit 1: curl: (7) <refused>", "exit 1: timeout"},
+		FormatMarkdown: {"*Failing health checks: 3*", "exit `1`: `curl: (7) <refused>`"},
+		FormatHTML:     {"<b>Failing health checks: 3</b>", "exit <code>1</code>: <code>curl: (7) &lt;refused&gt;</code>"},
+		FormatANSI:     {"Failing health checks: 3", "timeout"},
+	} {
+		result := DefaultTemplates().Render(format, event)
+		for _, part := range parts {
+			if !strings.Contains(result, part) {
+				t.Errorf("Expected %s to contain '%s', but got: %s", format, part, result)
+			}
+		}
+	}
+}
+
+func TestEventOOMKilled(t *testing.T) {
+	event := Event{
+		Type:        "container",
+		Action:      "die",
+		Name:        "api",
+		Image:       "api:1.2",
+		ExitCode:    "137",
+		OOMKilled:   true,
+		MemoryLimit: 512 * 1024 * 1024,
+	}
+
+	for format, part := range map[Format]string{
+		FormatText:     "container killed: out of memory (limit 512MiB) api",
+		FormatMarkdown: "container *killed: out of memory* (limit 512MiB) `api`",
+		FormatHTML:     "container <b>killed: out of memory</b> (limit 512MiB) <code>api</code>",
+	} {
+		result := DefaultTemplates().Render(format, event)
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected %s to contain '%s', but got: %s", format, part, result)
+		}
+	}
+
+	if event.Severity() != SeverityCritical {
+		t.Errorf("Expected OOM kill to be critical, got %s", event.Severity())
+	}
+
+	plain := event
+	plain.OOMKilled = false
+	summary := batchSummary(DefaultTemplates().Catalog(), []Event{event, plain})
+	if summary != "2 events" {
+		t.Errorf("Expected mixed batch summary, got %s", summary)
+	}
+	if subject := digestSubject(DefaultTemplates().Catalog(), []Event{event}, "host-x"); subject != "api ran out of memory on host-x" {
+		t.Errorf("Unexpected subject %s", subject)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for size, expected := range map[int64]string{
+		512:                    "512B",
+		1536:                   "1.5KiB",
+		512 * 1024 * 1024:      "512MiB",
+		3 * 1024 * 1024 * 1024: "3GiB",
+	} {
+		if result := formatBytes(size); result != expected {
+			t.Errorf("Expected %d to be formatted as %s, got %s", size, expected, result)
+		}
+	}
+}
+
+func TestEventServerInfo(t *testing.T) {
+	info := &ServerInfo{
+		Version:           "27.5.1",
+		Host:              "host-x",
+		OSType:            "linux",
+		OperatingSystem:   "Ubuntu 24.04 LTS",
+		KernelVersion:     "6.8.0-45-generic",
+		Architecture:      "x86_64",
+		CPUs:              8,
+		MemoryMB:          32000,
+		Uptime:            3*86400 + 4*3600,
+		ContainersRunning: 12,
+		ContainersStopped: 2,
+		Images:            40,
+		DiskUsed:          30 << 30,
+		DiskReclaimable:   4 << 30,
+		Failed:            []string{"worker"},
+	}
+	event := Event{Type: "summary", ServerInfo: info}
+
+	expected := "Status report\n" +
+		"Docker version: 27.5.1\n" +
+		"Docker host: host-x\n" +
+		"Type: linux\n" +
+		"Operating system: Ubuntu 24.04 LTS (kernel 6.8.0-45-generic)\n" +
+		"Architecture: x86_64\n" +
+		"CPUs: 8\n" +
+		"Memory: 32000 MB\n" +
+		"Uptime: 3d4h\n" +
+		"Containers: 12 running, 0 paused, 2 stopped\n" +
+		"Images: 40\n" +
+		"Disk usage: 30GiB, 4GiB reclaimable\n" +
+		"Exited with an error: worker"
+	if result := event.Text(); result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+	if result := event.HTML(); !strings.Contains(result, "<b>Exited with an error:</b> worker") {
+		t.Errorf("Expected html labels, got %s", result)
+	}
+
+	info.Failed = nil
+	if result := event.Text(); !strings.Contains(result, "Status: no failing containers") {
+		t.Errorf("Expected healthy status, got %s", result)
+	}
+
+	event.Type = "Server info"
+	if result := event.Text(); strings.Contains(result, "Status report") {
+		t.Errorf("Expected no report title at startup, got %s", result)
+	}
+
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	if result := listNames(names); result != "a, b, c, d, e, f, g, h, i, j (+2)" {
+		t.Errorf("Unexpected list %q", result)
+	}
+}
+
+func TestEventDisk(t *testing.T) {
+	event := Event{
+		Type:      "disk",
+		Action:    "threshold_exceeded",
+		Name:      "/var/lib/docker",
+		Metric:    "disk_free",
+		Value:     7.53,
+		Threshold: 10,
+		Disk: &DiskReport{
+			Path:                  "/var/lib/docker",
+			Total:                 100 << 30,
+			Free:                  7 << 30,
+			ImagesReclaimable:     12 << 30,
+			BuildCacheReclaimable: 512 << 20,
+			TopImages:             []DiskItem{{Name: "postgres:16", Size: 450 << 20}, {Name: "node:20", Size: 1 << 30}},
+			TopVolumes:            []DiskItem{{Name: "pgdata", Size: 20 << 30}},
+		},
+	}
+
+	expected := "disk over threshold /var/lib/docker free space %: 7.5 (threshold 10)\n" +
+		"Free: 7GiB of 100GiB\n" +
+		"Reclaimable: images 12GiB, build cache 512MiB\n" +
+		"Largest images: postgres:16 450MiB, node:20 1GiB\n" +
+		"Largest volumes: pgdata 20GiB"
+	if result := event.Text(); result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if result := event.HTML(); !strings.Contains(result, "<code>/var/lib/docker</code>") {
+		t.Errorf("Expected html path, got %s", result)
+	}
+
+	if title := eventTitle(DefaultTemplates().Catalog(), event); title != "Disk space over threshold" {
+		t.Errorf("Expected disk title, got %s", title)
+	}
+
+	event.Metric, event.Value, event.Threshold = "reclaimable", 12<<30, 10<<30
+	if result := event.Markdown(); !strings.Contains(result, "reclaimable: *12GiB* (threshold 10GiB)") {
+		t.Errorf("Expected reclaimable bytes, got %s", result)
+	}
+}
+
+func TestNewEventFromDocker_Resources(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      events.Message
+		expected string
+		check    func(t *testing.T, e Event)
+	}{
+		{
+			name: "image pull",
+			msg: events.Message{Type: events.ImageEventType, Action: "pull", Actor: events.Actor{
+				ID: "nginx:1.27", Attributes: map[string]string{"name": "nginx"},
+			}},
+			expected: "image pull nginx:1.27",
+		},
+		{
+			name: "image tag",
+			msg: events.Message{Type: events.ImageEventType, Action: "tag", Actor: events.Actor{
+				ID: "sha256:4f2a1c9e8b7d6a5f4e3d2c1b", Attributes: map[string]string{"name": "registry.local/nginx:stable"},
+			}},
+			expected: "image tag registry.local/nginx:stable (4f2a1c9e8b7d)",
+		},
+		{
+			name: "image delete",
+			msg: events.Message{Type: events.ImageEventType, Action: "delete", Actor: events.Actor{
+				ID: "sha256:4f2a1c9e8b7d6a5f4e3d2c1b", Attributes: map[string]string{"name": "sha256:4f2a1c9e8b7d6a5f4e3d2c1b"},
+			}},
+			expected: "image delete 4f2a1c9e8b7d",
+		},
+		{
+			name: "volume mount",
+			msg: events.Message{Type: events.VolumeEventType, Action: "mount", Actor: events.Actor{
+				ID: "pgdata", Attributes: map[string]string{"driver": "local", "container": "0123456789abcdef0123456789abcdef", "destination": "/var/lib/postgresql/data"},
+			}},
+			expected: "volume mount pgdata (driver local) container 0123456789abcdef0123:/var/lib/postgresql/data",
+			check: func(t *testing.T, e Event) {
+				if e.Container != "" {
+					t.Errorf("Expected no container for volume events, got %s", e.Container)
+				}
+			},
+		},
+		{
+			name: "network connect",
+			msg: events.Message{Type: events.NetworkEventType, Action: "connect", Actor: events.Actor{
+				ID: "9f8e7d", Attributes: map[string]string{"name": "shop_default", "type": "bridge", "container": "0123456789abcdef"},
+			}},
+			expected: "network connect shop_default (driver bridge) container 0123456789abcdef",
+			check: func(t *testing.T, e Event) {
+				e.ConnectedName = "web"
+				if text := e.Text(); !strings.HasSuffix(text, "container web") {
+					t.Errorf("Expected connected name, got %s", text)
+				}
+			},
+		},
+		{
+			name: "podman pod",
+			msg: events.Message{Type: "pod", Action: "stop", Actor: events.Actor{
+				ID: "8f2e4c1a", Attributes: map[string]string{"name": "pod_shop", "image": ""},
+			}},
+			expected: "pod stop pod_shop",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := NewEventFromDocker(tc.msg)
+			if text := event.Text(); text != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, text)
+			}
+			if tc.check != nil {
+				tc.check(t, event)
+			}
+		})
+	}
+}
+
+func TestEnableEvents(t *testing.T) {
+	original := EventActionMap{}
+	for eventType, actions := range SupportedEvents {
+		original[eventType] = maps.Clone(actions)
+	}
+	t.Cleanup(func() { SupportedEvents = original })
+
+	if (Event{Type: "network", Action: "connect"}).ShouldNotify(false) {
+		t.Fatal("Expected network events to be disabled by default")
+	}
+
+	if err := EnableEvents([]string{"network", "image:delete"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		event    Event
+		expected bool
+	}{
+		{Event{Type: "network", Action: "connect"}, true},
+		{Event{Type: "network", Action: "destroy"}, true},
+		{Event{Type: "image", Action: "delete"}, true},
+		{Event{Type: "image", Action: "pull"}, false},
+		{Event{Type: "volume", Action: "create"}, false},
+		{Event{Type: "container", Action: "die"}, true},
+	} {
+		if result := tc.event.ShouldNotify(false); result != tc.expected {
+			t.Errorf("Expected ShouldNotify(%s:%s) to be %v", tc.event.Type, tc.event.Action, tc.expected)
+		}
+	}
+
+	err := EnableEvents([]string{"image:push"})
+	if err == nil || !strings.Contains(err.Error(), "image:pull") {
+		t.Errorf("Expected unknown event error listing available events, got %v", err)
+	}
+}
+
+func TestNewEventFromDocker_Swarm(t *testing.T) {
+	service := NewEventFromDocker(events.Message{Type: events.ServiceEventType, Action: "update", Actor: events.Actor{
+		ID: "s1",
+		Attributes: map[string]string{
+			"name":            "shop_api",
+			"replicas.old":    "2",
+			"replicas.new":    "3",
+			"updatestate.old": "updating",
+			"updatestate.new": "paused",
+		},
+	}})
+
+	if service.Container != "" || service.Service != "shop_api" || service.Replicas != "3" || service.UpdateState != "paused" {
+		t.Errorf("Unexpected service event %+v", service)
+	}
+	if text := service.Text(); text != "service update shop_api replicas: 2 → 3 update: updating → paused" {
+		t.Errorf("Unexpected text %q", text)
+	}
+	if service.Severity() != SeverityCritical {
+		t.Errorf("Expected paused update to be critical, got %s", service.Severity())
+	}
+
+	node := NewEventFromDocker(events.Message{Type: events.NodeEventType, Action: "update", Actor: events.Actor{
+		ID:         "n1",
+		Attributes: map[string]string{"name": "worker-1", "state.old": "ready", "state.new": "down"},
+	}})
+	if node.Name != "worker-1" || node.NodeState != "down" || node.Severity() != SeverityCritical {
+		t.Errorf("Unexpected node event %+v", node)
+	}
+	if !node.ShouldNotify(false) {
+		t.Error("Expected node state changes to be notified")
+	}
+	if html := node.HTML(); !strings.Contains(html, "state: <code>ready</code> → <code>down</code>") {
+		t.Errorf("Unexpected html %s", html)
+	}
+
+	heartbeat := NewEventFromDocker(events.Message{Type: events.NodeEventType, Action: "update", Actor: events.Actor{
+		ID:         "n1",
+		Attributes: map[string]string{"name": "worker-1"},
+	}})
+	if heartbeat.ShouldNotify(false) {
+		t.Error("Expected updates without changes to be skipped")
+	}
+
+	container := NewEventFromDocker(events.Message{Type: events.ContainerEventType, Action: "die", Actor: events.Actor{
+		ID: "abc",
+		Attributes: map[string]string{
+			"name":                          "shop_api.2.x1y2z3",
+			"com.docker.stack.namespace":    "shop",
+			"com.docker.swarm.service.name": "shop_api",
+		},
+	}})
+	if container.Project != "shop" || container.Service != "shop_api" {
+		t.Errorf("Expected stack and service of task containers, got %s::%s", container.Project, container.Service)
+	}
+}
+
+func TestNewEventFromDocker_Daemon(t *testing.T) {
+	reload := NewEventFromDocker(events.Message{Type: events.DaemonEventType, Action: "reload", Actor: events.Actor{
+		ID:         "daemon-id",
+		Attributes: map[string]string{"name": "host-x", "debug": "false"},
+	}})
+	if reload.Container != "" || !reload.ShouldNotify(false) {
+		t.Errorf("Unexpected reload event %+v", reload)
+	}
+	if text := reload.Text(); text != "Docker daemon configuration reloaded on host-x" {
+		t.Errorf("Unexpected text %q", text)
+	}
+
+	unreachable := Event{Type: "daemon", Action: "unreachable", Name: "host-x", DaemonError: "Cannot connect to the Docker daemon"}
+	if unreachable.Severity() != SeverityCritical {
+		t.Errorf("Expected unreachable daemon to be critical, got %s", unreachable.Severity())
+	}
+	if md := unreachable.Markdown(); md != "*Docker daemon unreachable on host-x*: `Cannot connect to the Docker daemon`" {
+		t.Errorf("Unexpected markdown %q", md)
+	}
+	if title := eventTitle(DefaultTemplates().Catalog(), unreachable); title != "Docker daemon unreachable" {
+		t.Errorf("Unexpected title %q", title)
+	}
+}
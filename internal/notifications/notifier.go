@@ -1,23 +1,151 @@
 package notifications
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+
 	"github.com/lotas/docker-alerts/internal/config"
+	"github.com/lotas/docker-alerts/internal/i18n"
 )
 
-func CreateNotifier(cfg *config.Config) Notifier {
+// CreateNotifier builds the notifiers of cfg, the Readiness follows the external ones
+func CreateNotifier(cfg *config.Config) (Notifier, *Readiness, error) {
 	var notifiers []Notifier
 	var base []Notifier
 
+	if cfg.Locale != "" {
+		catalog, err := i18n.Load(cfg.Locale)
+		if err != nil {
+			return nil, nil, err
+		}
+		SetDefaultTemplates(DefaultTemplates().WithCatalog(catalog))
+	}
+
+	if cfg.TemplatesDir != "" {
+		templates, err := DefaultTemplates().WithDir(cfg.TemplatesDir, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		SetDefaultTemplates(templates)
+	}
+
+	readiness := &Readiness{}
+	// metric labels, a second notifier of the same kind gets a suffix
+	instrumentedNames := map[string]int{}
+	// console and file never block readiness
+	instrumented := func(name string, n Notifier, isExternal bool) Notifier {
+		if instrumentedNames[name]++; instrumentedNames[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, instrumentedNames[name])
+		}
+		i := NewInstrumentedNotifier(name, n)
+		if isExternal {
+			readiness.notifiers = append(readiness.notifiers, i)
+		}
+		return i
+	}
+
+	// "--summary-notifiers slack,smtp" keeps status reports away from the other notifiers,
+	// URL notifiers are named by their scheme
+	withSummary := func(name string, n Notifier) Notifier {
+		if len(cfg.SummaryNotifiers) == 0 || slices.Contains(cfg.SummaryNotifiers, name) {
+			return n
+		}
+		return NewTypeFilterNotifier(n, "summary")
+	}
+
+	// "--notifier-hosts slack=web1 web2" routes events of some docker hosts only
+	routed := func(name string, n Notifier) Notifier {
+		if hosts := strings.Fields(cfg.NotifierHosts[name]); len(hosts) > 0 {
+			n = NewHostFilterNotifier(n, hosts)
+		}
+		return withSummary(name, n)
+	}
+
+	// per notifier overrides, e.g. "telegram.html.tmpl" or "--notifier-locale telegram=ru"
+	templatesFor := func(name string) (*Templates, error) {
+		templates := DefaultTemplates()
+		if locale, ok := cfg.NotifierLocale[name]; ok {
+			catalog, err := i18n.Load(locale)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s locale: %w", name, err)
+			}
+			templates = templates.WithCatalog(catalog)
+		}
+		if cfg.TemplatesDir != "" {
+			return templates.WithDir(cfg.TemplatesDir, name+".")
+		}
+		return templates, nil
+	}
+	withTemplates := func(name string, n templateSetter) error {
+		templates, err := templatesFor(name)
+		if err != nil {
+			return err
+		}
+		if templates != DefaultTemplates() {
+			n.SetTemplates(templates)
+		}
+		return nil
+	}
+
 	consoleNotifier := NewConsoleNotifier("Docker",
 		WithColor(),
 	)
-	base = append(base, consoleNotifier)
+	if err := withTemplates("console", consoleNotifier); err != nil {
+		return nil, nil, err
+	}
+	base = append(base, withSummary("console", instrumented("console", consoleNotifier, false)))
+
+	if cfg.FilePath != "" {
+		// audit log is written right away, not debounced
+		fileNotifier := NewFileNotifier(cfg.FilePath,
+			WithMaxSize(cfg.FileMaxSize()),
+			WithMaxAge(cfg.FileMaxAge()),
+			WithRetention(cfg.FileMaxBackups, cfg.FileRetention()),
+		)
+		if err := withTemplates("file", fileNotifier); err != nil {
+			return nil, nil, err
+		}
+		base = append(base, withSummary("file", instrumented("file", fileNotifier, false)))
+	}
+
+	for _, rawURL := range cfg.Notify {
+		scheme := urlScheme(rawURL)
+		// the parameters of the URL go on top of the templates of its scheme
+		templates, err := templatesFor(scheme)
+		if err != nil {
+			return nil, nil, err
+		}
+		urlNotifier, err := newNotifierWithTemplates(rawURL, templates)
+		if err != nil {
+			return nil, nil, err
+		}
+		// file:// is an audit log like --file-path
+		isExternal := scheme != "file"
+		notifiers = append(notifiers, routed(scheme, instrumented(scheme, urlNotifier, isExternal)))
+	}
+
+	if cfg.SlackToken != "" && cfg.SlackChannel != "" {
+		slackNotifier := NewSlackBotNotifier(
+			cfg.SlackToken,
+			cfg.SlackChannel,
+		)
+		if err := withTemplates("slack", slackNotifier); err != nil {
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, routed("slack", instrumented("slack_bot", slackNotifier, true)))
+	}
 
 	if cfg.SlackWebhookURL != "" {
 		slackNotifier := NewSlackNotifier(
 			cfg.SlackWebhookURL,
 		)
-		notifiers = append(notifiers, slackNotifier)
+		if err := withTemplates("slack", slackNotifier); err != nil {
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, routed("slack", instrumented("slack_webhook", slackNotifier, true)))
 	}
 
 	if cfg.TelegramToken != "" && cfg.TelegramChatID != "" {
@@ -25,25 +153,63 @@ func CreateNotifier(cfg *config.Config) Notifier {
 			cfg.TelegramToken,
 			cfg.TelegramChatID,
 		)
-		notifiers = append(notifiers, telegramNotifier)
+		if err := withTemplates("telegram", telegramNotifier); err != nil {
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, routed("telegram", instrumented("telegram", telegramNotifier, true)))
 	}
 
 	if cfg.EmailSMTPHost != "" {
+		to := splitValues(cfg.EmailTo)
+		if len(to) == 0 {
+			return nil, nil, fmt.Errorf("email notifier needs at least one --email-to address")
+		}
 		emailNotifier := NewEmailNotifier(
 			cfg.EmailSMTPHost,
 			cfg.EmailSMTPPort,
 			cfg.EmailFrom,
-			cfg.EmailTo,
+			to,
 		)
 
 		if cfg.EmailSMTPUsername != "" && cfg.EmailSMTPPassword != "" {
-			emailNotifier.SetAuth(
+			err := emailNotifier.SetAuthMechanism(
+				cfg.EmailAuth,
 				cfg.EmailSMTPUsername,
 				cfg.EmailSMTPPassword,
 			)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		err := emailNotifier.SetTLS(
+			EmailTLSMode(cfg.EmailTLS),
+			cfg.EmailCAFile,
+			cfg.EmailSkipVerify,
+		)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		notifiers = append(notifiers, emailNotifier)
+		if err := withTemplates("email", emailNotifier); err != nil {
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, routed("email", instrumented("email", emailNotifier, true)))
+	}
+
+	command, err := shellwords.Parse(cfg.ExecCommand)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid exec command: %w", err)
+	}
+	if len(command) > 0 {
+		execNotifier := NewExecNotifier(command,
+			WithExecTimeout(cfg.ExecTimeout()),
+			WithExecConcurrency(cfg.ExecConcurrency),
+		)
+		if err := withTemplates("exec", execNotifier); err != nil {
+			return nil, nil, err
+		}
+		notifiers = append(notifiers, routed("exec", instrumented("exec", execNotifier, true)))
 	}
 
 	if len(notifiers) > 0 {
@@ -63,5 +229,5 @@ func CreateNotifier(cfg *config.Config) Notifier {
 		notifier = NewMultiNotifier(base...)
 	}
 
-	return notifier
+	return notifier, readiness, nil
 }
@@ -0,0 +1,105 @@
+package notifications
+
+import (
+	"context"
+)
+
+// eventFilter is a notifier that drops some events without sending them
+type eventFilter interface {
+	accepts(event Event) bool
+}
+
+// HostFilterNotifier forwards only the events of some docker hosts
+type HostFilterNotifier struct {
+	notifier Notifier
+	hosts    map[string]bool
+}
+
+func NewHostFilterNotifier(notifier Notifier, hosts []string) *HostFilterNotifier {
+	f := &HostFilterNotifier{
+		notifier: notifier,
+		hosts:    map[string]bool{},
+	}
+	for _, host := range hosts {
+		f.hosts[host] = true
+	}
+	return f
+}
+
+func (f *HostFilterNotifier) accepts(event Event) bool {
+	return f.hosts[event.Host]
+}
+
+func (f *HostFilterNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	if !f.accepts(event) {
+		return nil
+	}
+	return f.notifier.Notify(ctx, event, debug)
+}
+
+func (f *HostFilterNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	var matching []Event
+	for _, e := range events {
+		if f.accepts(e) {
+			matching = append(matching, e)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	return f.notifier.NotifyMultiple(ctx, matching, debug)
+}
+
+// commonHost is the host of all events, empty when they differ
+func commonHost(events []Event) string {
+	if len(events) == 0 {
+		return ""
+	}
+	for _, e := range events[1:] {
+		if e.Host != events[0].Host {
+			return ""
+		}
+	}
+	return events[0].Host
+}
+
+// TypeFilterNotifier drops the events of some types, e.g. status reports for a busy channel
+type TypeFilterNotifier struct {
+	notifier Notifier
+	skip     map[string]bool
+}
+
+func NewTypeFilterNotifier(notifier Notifier, skip ...string) *TypeFilterNotifier {
+	f := &TypeFilterNotifier{
+		notifier: notifier,
+		skip:     map[string]bool{},
+	}
+	for _, eventType := range skip {
+		f.skip[eventType] = true
+	}
+	return f
+}
+
+func (f *TypeFilterNotifier) accepts(event Event) bool {
+	return !f.skip[event.Type]
+}
+
+func (f *TypeFilterNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	if !f.accepts(event) {
+		return nil
+	}
+	return f.notifier.Notify(ctx, event, debug)
+}
+
+func (f *TypeFilterNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	var matching []Event
+	for _, e := range events {
+		if f.accepts(e) {
+			matching = append(matching, e)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	return f.notifier.NotifyMultiple(ctx, matching, debug)
+}
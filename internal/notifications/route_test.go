@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	events []Event
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func TestHostFilterNotifier(t *testing.T) {
+	recorder := &recordingNotifier{}
+	notifier := NewHostFilterNotifier(recorder, []string{"web1", "web2"})
+	ctx := context.Background()
+
+	require.NoError(t, notifier.Notify(ctx, Event{Host: "web1", Name: "a"}, false))
+	require.NoError(t, notifier.Notify(ctx, Event{Host: "db", Name: "b"}, false))
+	require.NoError(t, notifier.NotifyMultiple(ctx, []Event{
+		{Host: "db", Name: "c"},
+		{Host: "web2", Name: "d"},
+	}, false))
+	require.NoError(t, notifier.NotifyMultiple(ctx, []Event{{Host: "db", Name: "e"}}, false))
+
+	var names []string
+	for _, e := range recorder.events {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"a", "d"}, names)
+}
+
+func TestTypeFilterNotifier(t *testing.T) {
+	recorder := &recordingNotifier{}
+	notifier := NewTypeFilterNotifier(recorder, "summary")
+	ctx := context.Background()
+
+	require.NoError(t, notifier.Notify(ctx, Event{Type: "summary"}, false))
+	require.NoError(t, notifier.NotifyMultiple(ctx, []Event{{Type: "summary"}, {Type: "container", Name: "api"}}, false))
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, "api", recorder.events[0].Name)
+}
+
+func TestHostPrefix(t *testing.T) {
+	event := Event{Host: "web1", Type: "container", Action: "start", Name: "api"}
+	assert.Contains(t, event.Text(), "[web1] ")
+
+	event.Host = ""
+	assert.NotContains(t, event.Text(), "[")
+
+	assert.Equal(t, "web1", commonHost([]Event{{Host: "web1"}, {Host: "web1"}}))
+	assert.Equal(t, "", commonHost([]Event{{Host: "web1"}, {Host: "db"}}))
+}
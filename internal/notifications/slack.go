@@ -2,11 +2,53 @@ package notifications
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/slack-go/slack"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
 )
 
+// Slack allows up to 100 attachments, keep messages readable well below that
+const slackMaxAttachments = 20
+
+const slackMaxHeaderLength = 150
+
+const slackMaxSectionLength = 3000
+
+const slackThreadTTL = 24 * time.Hour
+
+var severityColors = map[Severity]string{
+	SeverityCritical: "danger",
+	SeverityWarning:  "warning",
+	SeverityOK:       "good",
+	SeverityInfo:     "#439FE0",
+}
+
+// SlackNotifier sends messages either through an incoming webhook or,
+// when created with a bot token, through the Web API. In Web API mode
+// follow-up events for a container are threaded under its first alert
+// and that alert is updated to reflect the latest state.
 type SlackNotifier struct {
+	templated
 	webhookURL string
+
+	api     *slack.Client
+	channel string
+
+	mu      sync.Mutex
+	threads map[string]*slackThread
+	now     func() time.Time
+}
+
+// slackThread is a posted message, shared by all containers it mentions
+type slackThread struct {
+	ts       string
+	postedAt time.Time
+	events   []Event
 }
 
 func NewSlackNotifier(webhookURL string) *SlackNotifier {
@@ -15,19 +57,225 @@ func NewSlackNotifier(webhookURL string) *SlackNotifier {
 	}
 }
 
+func NewSlackBotNotifier(token, channel string, options ...slack.Option) *SlackNotifier {
+	return &SlackNotifier{
+		api:     slack.New(token, options...),
+		channel: channel,
+		threads: map[string]*slackThread{},
+		now:     time.Now,
+	}
+}
+
 func (s *SlackNotifier) Notify(ctx context.Context, event Event, debug bool) error {
-	msg := slack.WebhookMessage{
-		Text: event.Markdown(),
+	return s.NotifyMultiple(ctx, []Event{event}, debug)
+}
+
+func (s *SlackNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	if len(events) == 0 {
+		return nil
 	}
 
-	return slack.PostWebhook(s.webhookURL, &msg)
+	if s.api == nil {
+		msg := slack.WebhookMessage{
+			Text: s.renderBatch(FormatMarkdown, events),
+		}
+		return slack.PostWebhookContext(ctx, s.webhookURL, &msg)
+	}
+
+	// the lock only guards the threads, Slack API calls are made without it
+	s.mu.Lock()
+	s.expireThreadsLocked()
+
+	var fresh []Event
+	followUps := map[*slackThread][]Event{}
+	var order []*slackThread
+	for _, e := range events {
+		thread, ok := s.threads[e.Container]
+		if !ok || e.Container == "" {
+			fresh = append(fresh, e)
+			continue
+		}
+		if _, seen := followUps[thread]; !seen {
+			order = append(order, thread)
+		}
+		followUps[thread] = append(followUps[thread], e)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	if len(fresh) > 0 {
+		if err := s.post(ctx, fresh, debug); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, thread := range order {
+		if err := s.reply(ctx, thread, followUps[thread], debug); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *SlackNotifier) post(ctx context.Context, events []Event, debug bool) error {
+	if debug {
+		fmt.Printf("Posting %d events to slack channel %s\n", len(events), s.channel)
+	}
+
+	_, ts, err := s.api.PostMessageContext(ctx, s.channel, s.messageOptions(events)...)
+	if err != nil {
+		return fmt.Errorf("failed to post slack message: %w", err)
+	}
+
+	thread := &slackThread{
+		ts:       ts,
+		postedAt: s.now(),
+		events:   events,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		if e.Container != "" {
+			s.threads[e.Container] = thread
+		}
+	}
+
+	return nil
 }
 
-func (c *SlackNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
-	for _, n := range events {
-		if err := c.Notify(ctx, n, debug); err != nil {
-			return err
+// reply posts events to the thread, the reply is what counts as delivered, updating
+// the original alert is best effort
+func (s *SlackNotifier) reply(ctx context.Context, thread *slackThread, events []Event, debug bool) error {
+	if debug {
+		fmt.Printf("Replying with %d events to slack thread %s\n", len(events), thread.ts)
+	}
+
+	options := append(s.messageOptions(events), slack.MsgOptionTS(thread.ts))
+	if _, _, err := s.api.PostMessageContext(ctx, s.channel, options...); err != nil {
+		return fmt.Errorf("failed to post slack reply: %w", err)
+	}
+
+	// reflect the latest state of each container in the original alert
+	s.mu.Lock()
+	for _, e := range events {
+		for i, original := range thread.events {
+			if original.Container == e.Container {
+				thread.events[i] = e
+			}
 		}
 	}
+	latest := append([]Event(nil), thread.events...)
+	s.mu.Unlock()
+
+	_, _, _, err := s.api.UpdateMessageContext(ctx, s.channel, thread.ts, s.messageOptions(latest)...)
+	if err != nil {
+		fmt.Printf("Failed to update slack message %s: %v\n", thread.ts, err)
+	}
+
 	return nil
 }
+
+// must be called when lock is held
+func (s *SlackNotifier) expireThreadsLocked() {
+	for container, thread := range s.threads {
+		if s.now().Sub(thread.postedAt) > slackThreadTTL {
+			delete(s.threads, container)
+		}
+	}
+}
+
+func (s *SlackNotifier) messageOptions(events []Event) []slack.MsgOption {
+	summary := batchSummary(s.catalog(), events)
+
+	shown := events
+	if len(shown) > slackMaxAttachments {
+		shown = shown[:slackMaxAttachments]
+	}
+
+	attachments := make([]slack.Attachment, 0, len(shown))
+	for _, e := range shown {
+		attachments = append(attachments, s.attachment(e))
+	}
+
+	header := summary
+	if runes := []rune(header); len(runes) > slackMaxHeaderLength {
+		header = string(runes[:slackMaxHeaderLength-1]) + "…"
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, header, true, false)),
+	}
+	if hidden := len(events) - len(shown); hidden > 0 {
+		blocks = append(blocks, slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, s.catalog().T("summary.more", hidden), false, false),
+		))
+	}
+
+	return []slack.MsgOption{
+		// fallback for notifications and clients without block support
+		slack.MsgOptionText(summary, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionAttachments(attachments...),
+	}
+}
+
+func (s *SlackNotifier) attachment(e Event) slack.Attachment {
+	logs := e.Logs
+	e.Logs = ""
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, s.render(FormatMarkdown, e), false, false), nil, nil),
+	}
+	if logs != "" {
+		// keep the end of the logs, it usually has the error
+		text := codeBlock(tailRunes(logs, slackMaxSectionLength-8))
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+	}
+
+	catalog := s.catalog()
+	field := func(key, value string) slack.MixedElement {
+		return slack.NewTextBlockObject(slack.MarkdownType, "*"+catalog.T(key)+":* "+value, false, false)
+	}
+
+	var fields []slack.MixedElement
+	if e.Image != "" {
+		fields = append(fields, field("field.image", e.Image))
+	}
+	if e.Project != "" {
+		fields = append(fields, field("field.project", e.Project))
+	}
+	if e.ExitCode != "" {
+		fields = append(fields, field("template.exit_code", e.ExitCode))
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", fields...))
+	}
+
+	return slack.Attachment{
+		Color:  severityColors[e.Severity()],
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// batchSummary is a short headline for a batch, e.g. "3 containers stopped"
+func batchSummary(catalog *i18n.Catalog, events []Event) string {
+	if len(events) == 1 {
+		if summary, ok := pastTense(catalog, events[0]); ok && events[0].Message == "" {
+			return catalog.T("summary.single", events[0].Name, summary)
+		}
+		return eventTitle(catalog, events[0])
+	}
+
+	summary, ok := pastTense(catalog, events[0])
+	if !ok {
+		return catalog.T("summary.events", len(events))
+	}
+	for _, e := range events[1:] {
+		if other, _ := pastTense(catalog, e); other != summary {
+			return catalog.T("summary.events", len(events))
+		}
+	}
+	return catalog.T("summary.containers", len(events), summary)
+}
@@ -0,0 +1,199 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
+)
+
+type slackCall struct {
+	method string
+	form   map[string]string
+}
+
+// newFakeSlackAPI records Web API calls and answers each with a new timestamp
+func newFakeSlackAPI(t *testing.T) (*httptest.Server, func() []slackCall) {
+	t.Helper()
+	return newFailingSlackAPI(t, func(slackCall) bool { return false })
+}
+
+// newFailingSlackAPI is newFakeSlackAPI answering the calls matched by fail with an error
+func newFailingSlackAPI(t *testing.T, fail func(slackCall) bool) (*httptest.Server, func() []slackCall) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []slackCall
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		mu.Lock()
+		call := slackCall{method: strings.TrimPrefix(r.URL.Path, "/"), form: map[string]string{}}
+		for key := range r.PostForm {
+			call.form[key] = r.PostForm.Get(key)
+		}
+		calls = append(calls, call)
+		ts := fmt.Sprintf("1700000000.%06d", len(calls))
+		mu.Unlock()
+
+		if fail(call) {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "internal_error"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": "C123", "ts": ts})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []slackCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]slackCall(nil), calls...)
+	}
+}
+
+func TestSlackBotNotifier_BatchInSingleMessage(t *testing.T) {
+	server, calls := newFakeSlackAPI(t)
+	notifier := NewSlackBotNotifier("xoxb-test", "C123", slack.OptionAPIURL(server.URL+"/"))
+
+	events := []Event{
+		{Type: "container", Action: "die", Container: "c1", Name: "api", Image: "api:1", ExitCode: "137", Project: "shop"},
+		{Type: "container", Action: "die", Container: "c2", Name: "web", Image: "web:1", ExitCode: "0"},
+	}
+
+	err := notifier.NotifyMultiple(context.Background(), events, false)
+	require.NoError(t, err)
+
+	recorded := calls()
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "chat.postMessage", recorded[0].method)
+	assert.Equal(t, "C123", recorded[0].form["channel"])
+	assert.Equal(t, "2 containers stopped", recorded[0].form["text"])
+	assert.Contains(t, recorded[0].form["blocks"], `"type":"header"`)
+
+	var attachments []slack.Attachment
+	require.NoError(t, json.Unmarshal([]byte(recorded[0].form["attachments"]), &attachments))
+	require.Len(t, attachments, 2)
+	assert.Equal(t, "danger", attachments[0].Color)
+	assert.Equal(t, "warning", attachments[1].Color)
+	assert.Contains(t, recorded[0].form["attachments"], "*Exit code:* 137")
+	assert.Contains(t, recorded[0].form["attachments"], "*Project:* shop")
+}
+
+func TestSlackBotNotifier_Translated(t *testing.T) {
+	server, calls := newFakeSlackAPI(t)
+	notifier := NewSlackBotNotifier("xoxb-test", "C123", slack.OptionAPIURL(server.URL+"/"))
+	ru, err := i18n.Load("ru")
+	require.NoError(t, err)
+	notifier.SetTemplates(DefaultTemplates().WithCatalog(ru))
+
+	var events []Event
+	for i := range slackMaxAttachments + 2 {
+		events = append(events, Event{Type: "container", Action: "die", Container: fmt.Sprint(i), Name: "api", Image: "api:1", ExitCode: "1", Project: "shop"})
+	}
+	require.NoError(t, notifier.NotifyMultiple(context.Background(), events, false))
+
+	recorded := calls()
+	require.Len(t, recorded, 1)
+	assert.Contains(t, recorded[0].form["attachments"], "*Образ:* api:1")
+	assert.Contains(t, recorded[0].form["attachments"], "*Проект:* shop")
+	assert.Contains(t, recorded[0].form["attachments"], "*Код выхода:* 1")
+	assert.Contains(t, recorded[0].form["blocks"], "…и ещё 2")
+}
+
+func TestSlackBotNotifier_ThreadsFollowUps(t *testing.T) {
+	server, calls := newFakeSlackAPI(t)
+	notifier := NewSlackBotNotifier("xoxb-test", "C123", slack.OptionAPIURL(server.URL+"/"))
+	ctx := context.Background()
+
+	err := notifier.Notify(ctx, Event{Type: "container", Action: "health_status: unhealthy", Container: "c1", Name: "api"}, false)
+	require.NoError(t, err)
+
+	err = notifier.NotifyMultiple(ctx, []Event{
+		{Type: "container", Action: "health_status: healthy", Container: "c1", Name: "api"},
+		{Type: "container", Action: "start", Container: "c2", Name: "web"},
+	}, false)
+	require.NoError(t, err)
+
+	recorded := calls()
+	require.Len(t, recorded, 4)
+
+	rootTS := "1700000000.000001"
+	assert.Empty(t, recorded[0].form["thread_ts"])
+
+	// new container gets its own message
+	assert.Equal(t, "chat.postMessage", recorded[1].method)
+	assert.Empty(t, recorded[1].form["thread_ts"])
+	assert.Equal(t, "web started", recorded[1].form["text"])
+
+	// follow-up goes into the thread and the original alert is updated
+	assert.Equal(t, "chat.postMessage", recorded[2].method)
+	assert.Equal(t, rootTS, recorded[2].form["thread_ts"])
+	assert.Equal(t, "api became healthy", recorded[2].form["text"])
+
+	assert.Equal(t, "chat.update", recorded[3].method)
+	assert.Equal(t, rootTS, recorded[3].form["ts"])
+	assert.Contains(t, recorded[3].form["attachments"], `"color":"good"`)
+}
+
+func TestSlackBotNotifier_FailedPostKeepsFollowUps(t *testing.T) {
+	server, calls := newFailingSlackAPI(t, func(call slackCall) bool {
+		return call.method == "chat.postMessage" && call.form["text"] == "web started"
+	})
+	notifier := NewSlackBotNotifier("xoxb-test", "C123", slack.OptionAPIURL(server.URL+"/"))
+	ctx := context.Background()
+
+	require.NoError(t, notifier.Notify(ctx, Event{Type: "container", Action: "health_status: unhealthy", Container: "c1", Name: "api"}, false))
+
+	err := notifier.NotifyMultiple(ctx, []Event{
+		{Type: "container", Action: "health_status: healthy", Container: "c1", Name: "api"},
+		{Type: "container", Action: "start", Container: "c2", Name: "web"},
+	}, false)
+	require.ErrorContains(t, err, "failed to post slack message")
+
+	// the follow-up is still threaded after the new message failed
+	recorded := calls()
+	require.Len(t, recorded, 4)
+	assert.Equal(t, "1700000000.000001", recorded[2].form["thread_ts"])
+	assert.Equal(t, "api became healthy", recorded[2].form["text"])
+	assert.Equal(t, "chat.update", recorded[3].method)
+}
+
+func TestSlackBotNotifier_FailedUpdateIsNotAnError(t *testing.T) {
+	server, calls := newFailingSlackAPI(t, func(call slackCall) bool {
+		return call.method == "chat.update"
+	})
+	notifier := NewSlackBotNotifier("xoxb-test", "C123", slack.OptionAPIURL(server.URL+"/"))
+	ctx := context.Background()
+
+	require.NoError(t, notifier.Notify(ctx, Event{Type: "container", Action: "health_status: unhealthy", Container: "c1", Name: "api"}, false))
+	require.NoError(t, notifier.Notify(ctx, Event{Type: "container", Action: "health_status: healthy", Container: "c1", Name: "api"}, false))
+
+	recorded := calls()
+	require.Len(t, recorded, 3)
+	assert.Equal(t, "1700000000.000001", recorded[1].form["thread_ts"])
+	assert.Equal(t, "chat.update", recorded[2].method)
+}
+
+func TestBatchSummary(t *testing.T) {
+	assert.Equal(t, "api stopped", batchSummary(i18n.English(), []Event{{Type: "container", Action: "die", Name: "api"}}))
+	assert.Equal(t, "Server info", batchSummary(i18n.English(), []Event{{Type: "Server info", Message: "..."}}))
+	assert.Equal(t, "2 containers started", batchSummary(i18n.English(), []Event{
+		{Type: "container", Action: "start"},
+		{Type: "container", Action: "start"},
+	}))
+	assert.Equal(t, "2 events", batchSummary(i18n.English(), []Event{
+		{Type: "container", Action: "start"},
+		{Type: "container", Action: "die"},
+	}))
+}
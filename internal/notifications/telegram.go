@@ -1,15 +1,23 @@
 package notifications
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
+	"unicode/utf8"
 )
 
+// Telegram rejects messages longer than this
+const telegramMaxMessageLength = 4096
+
 type TelegramNotifier struct {
+	templated
 	token  string
 	chatID string
 	client *http.Client
@@ -69,20 +77,75 @@ func (t *TelegramNotifier) sendMessage(ctx context.Context, chatId string, messa
 	return nil
 }
 
-func (t *TelegramNotifier) Notify(ctx context.Context, event Event, debug bool) error {
-	message := event.HTML()
+// sendDocument uploads container logs that do not fit into a message
+func (t *TelegramNotifier) sendDocument(ctx context.Context, chatId string, file logFile, debug bool) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", t.token)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("chat_id", chatId)
+	part, err := writer.CreateFormFile("document", file.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	part.Write([]byte(file.Content))
+	writer.Close()
+
+	if debug {
+		fmt.Printf("Sending TG document %s\n", file.Name)
+	}
 
-	return t.sendMessage(ctx, t.chatID, message, debug)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telegram document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("telegram API returned non-200 status code: %d\n%v\n", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (t *TelegramNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return t.send(ctx, []Event{event}, debug)
 }
 
 func (t *TelegramNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
 	// TODO: group by chatId, allow docker lables to override chat id
 
-	messages := []string{}
+	return t.send(ctx, events, debug)
+}
+
+func (t *TelegramNotifier) send(ctx context.Context, events []Event, debug bool) error {
+	render := func(events []Event) string {
+		return t.renderBatch(FormatHTML, events)
+	}
+	message := render(events)
+	attach := logsNotRendered(message, events, render)
+	if utf8.RuneCountInString(message) > telegramMaxMessageLength && hasLogs(events) {
+		// logs do not fit, send them as files after the alert
+		message = render(withoutLogs(events))
+		attach = events
+	}
 
-	for _, n := range events {
-		messages = append(messages, n.HTML())
+	if err := t.sendMessage(ctx, t.chatID, message, debug); err != nil {
+		return err
 	}
 
-	return t.sendMessage(ctx, t.chatID, strings.Join(messages, "\n"), debug)
+	var errs []error
+	for _, file := range logFiles(attach) {
+		if err := t.sendDocument(ctx, t.chatID, file, debug); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,437 @@
+package notifications
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
+)
+
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatANSI     Format = "ansi"
+)
+
+var Formats = []Format{FormatText, FormatMarkdown, FormatHTML, FormatANSI}
+
+// Templates holds per-event and batch templates for every output format
+type Templates struct {
+	byFormat      map[Format]*template.Template
+	batchByFormat map[Format]*template.Template
+	catalog       *i18n.Catalog
+}
+
+var defaultTemplates atomic.Pointer[Templates]
+
+func init() {
+	templates, err := newTemplates(map[Format]string{
+		FormatText:     textTpl,
+		FormatMarkdown: mdTpl,
+		FormatHTML:     htmlTpl,
+		FormatANSI:     ansiTpl,
+	}, map[Format]string{
+		FormatText:     batchTextTpl,
+		FormatMarkdown: batchMdTpl,
+		FormatHTML:     batchHTMLTpl,
+		FormatANSI:     batchANSITpl,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Failed to parse built-in templates: %v", err))
+	}
+
+	defaultTemplates.Store(templates)
+}
+
+// DefaultTemplates are used by notifiers without their own templates
+func DefaultTemplates() *Templates {
+	return defaultTemplates.Load()
+}
+
+func SetDefaultTemplates(templates *Templates) {
+	defaultTemplates.Store(templates)
+}
+
+func newTemplates(sources, batchSources map[Format]string) (*Templates, error) {
+	t := &Templates{
+		byFormat:      map[Format]*template.Template{},
+		batchByFormat: map[Format]*template.Template{},
+		catalog:       i18n.English(),
+	}
+	for format, source := range sources {
+		tpl, err := template.New(string(format)).Funcs(templateFuncs(t.catalog)).Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		t.byFormat[format] = tpl
+	}
+	for format, source := range batchSources {
+		tpl, err := template.New("batch." + string(format)).Funcs(templateFuncs(t.catalog)).Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		t.batchByFormat[format] = tpl
+	}
+	return t, nil
+}
+
+func (t *Templates) Render(format Format, e Event) string {
+	var buf bytes.Buffer
+	err := t.byFormat[format].Execute(&buf, e)
+	if err != nil {
+		fmt.Printf("Error generating %s template: %v\n", format, err)
+		// cheap fallback
+		return e.Type + " " + e.Action + " " + e.Name
+	}
+
+	return buf.String()
+}
+
+// RenderBatch renders several events at once, single events are rendered as usual
+func (t *Templates) RenderBatch(format Format, events []Event) string {
+	if len(events) == 1 {
+		return t.Render(format, events[0])
+	}
+
+	batch := Batch{Events: make([]BatchEvent, 0, len(events))}
+	for _, e := range events {
+		rendered := strings.TrimRight(t.Render(format, e), "\n")
+		batch.Events = append(batch.Events, BatchEvent{Event: e, Rendered: rendered})
+	}
+
+	var buf bytes.Buffer
+	err := t.batchByFormat[format].Execute(&buf, batch)
+	if err != nil {
+		fmt.Printf("Error generating %s batch template: %v\n", format, err)
+		// fall back to one rendering per line
+		lines := make([]string, 0, len(batch.Events))
+		for _, e := range batch.Events {
+			lines = append(lines, e.Rendered)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return buf.String()
+}
+
+// WithFile returns a copy of the templates with the given format read from path.
+// The template is validated by rendering a few sample events.
+func (t *Templates) WithFile(format Format, path string) (*Templates, error) {
+	return t.withFile(format, path, false)
+}
+
+// WithBatchFile is like WithFile but replaces the batch template
+func (t *Templates) WithBatchFile(format Format, path string) (*Templates, error) {
+	return t.withFile(format, path, true)
+}
+
+func (t *Templates) withFile(format Format, path string, batch bool) (*Templates, error) {
+	if _, ok := t.byFormat[format]; !ok {
+		return nil, fmt.Errorf("unknown template format %q", format)
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	// naming the template after the file makes errors point at "path:line"
+	tpl, err := template.New(path).Funcs(templateFuncs(t.catalog)).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", format, err)
+	}
+
+	var samples []any
+	if batch {
+		sample := Batch{}
+		for _, e := range append(sampleEvents, sampleEvents...) {
+			sample.Events = append(sample.Events, BatchEvent{Event: e, Rendered: t.Render(format, e)})
+		}
+		samples = append(samples, sample)
+	} else {
+		for _, e := range sampleEvents {
+			samples = append(samples, e)
+		}
+	}
+
+	for _, sample := range samples {
+		if err := tpl.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", format, err)
+		}
+	}
+
+	copied := &Templates{
+		byFormat:      maps.Clone(t.byFormat),
+		batchByFormat: maps.Clone(t.batchByFormat),
+		catalog:       t.catalog,
+	}
+	if batch {
+		copied.batchByFormat[format] = tpl
+	} else {
+		copied.byFormat[format] = tpl
+	}
+
+	return copied, nil
+}
+
+// WithCatalog returns a copy of the templates translated with the given catalog
+func (t *Templates) WithCatalog(catalog *i18n.Catalog) *Templates {
+	copied := &Templates{
+		byFormat:      map[Format]*template.Template{},
+		batchByFormat: map[Format]*template.Template{},
+		catalog:       catalog,
+	}
+	funcs := templateFuncs(catalog)
+	for format, tpl := range t.byFormat {
+		copied.byFormat[format] = template.Must(tpl.Clone()).Funcs(funcs)
+	}
+	for format, tpl := range t.batchByFormat {
+		copied.batchByFormat[format] = template.Must(tpl.Clone()).Funcs(funcs)
+	}
+	return copied
+}
+
+func (t *Templates) Catalog() *i18n.Catalog {
+	return t.catalog
+}
+
+// WithDir overrides every format that has a "<prefix><format>.tmpl" file in dir,
+// e.g. "html.tmpl" for all notifiers or "telegram.html.tmpl" for one of them.
+// Batch templates are read from "<prefix>batch.<format>.tmpl".
+func (t *Templates) WithDir(dir, prefix string) (*Templates, error) {
+	result := t
+	for _, batch := range []bool{false, true} {
+		for _, format := range Formats {
+			name := prefix + string(format) + ".tmpl"
+			if batch {
+				name = prefix + "batch." + string(format) + ".tmpl"
+			}
+
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			var err error
+			result, err = result.withFile(format, path, batch)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+type templateSetter interface {
+	SetTemplates(templates *Templates)
+}
+
+// templated is embedded by notifiers to allow overriding their templates
+type templated struct {
+	templates *Templates
+}
+
+func (t *templated) SetTemplates(templates *Templates) {
+	t.templates = templates
+}
+
+func (t *templated) render(format Format, e Event) string {
+	if t.templates == nil {
+		return DefaultTemplates().Render(format, e)
+	}
+	return t.templates.Render(format, e)
+}
+
+func (t *templated) catalog() *i18n.Catalog {
+	if t.templates == nil {
+		return DefaultTemplates().Catalog()
+	}
+	return t.templates.Catalog()
+}
+
+func (t *templated) renderBatch(format Format, events []Event) string {
+	if t.templates == nil {
+		return DefaultTemplates().RenderBatch(format, events)
+	}
+	return t.templates.RenderBatch(format, events)
+}
+
+var sampleEvents = []Event{
+	{
+		Type:            "container",
+		Action:          "die",
+		Container:       "4f2a1c9e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f",
+		Image:           "nginx:latest",
+		Time:            1700000000,
+		Labels:          map[string]string{"com.docker.compose.project": "shop"},
+		Name:            "web",
+		Project:         "shop",
+		Service:         "web",
+		ExitCode:        "137",
+		ExitCodeDetails: "Immediate termination SIGKILL",
+		ExecDuration:    "42",
+		Logs:            "panic: out of memory",
+	},
+	{Type: "container", Action: "start"},
+	{
+		Type:                "container",
+		Action:              "health_status: unhealthy",
+		Name:                "api",
+		HealthLog:           []HealthCheck{{Start: 1700000000, End: 1700000001, ExitCode: 1, Output: "connection refused"}},
+		HealthFailingStreak: 3,
+	},
+	{Type: "Server info", Message: "Docker version: 27.0.0", ServerInfo: &ServerInfo{Version: "27.0.0"}},
+	{Type: "network", Action: "connect", Name: "shop_default", Driver: "bridge", ConnectedContainer: "4f2a1c9e8b7d", ConnectedName: "web"},
+	{
+		Type:      "disk",
+		Action:    "threshold_exceeded",
+		Name:      "/var/lib/docker",
+		Metric:    "disk_free",
+		Value:     7.5,
+		Threshold: 10,
+		Disk: &DiskReport{
+			Path:              "/var/lib/docker",
+			Total:             100 << 30,
+			Free:              7 << 30,
+			ImagesReclaimable: 12 << 30,
+			TopImages:         []DiskItem{{Name: "postgres:16", Size: 450 << 20}},
+		},
+	},
+}
+
+var localHostname = sync.OnceValue(func() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return name
+})
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, exp := float64(size), 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + []string{"", "KiB", "MiB", "GiB", "TiB"}[exp]
+}
+
+// metrics measured in bytes, the rest are percentages or counts
+var byteMetrics = map[string]bool{"reclaimable": true}
+
+func formatMetric(metric string, value float64) string {
+	if byteMetrics[metric] {
+		return formatBytes(int64(value))
+	}
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+func shortID(s string) string {
+	if len(s) > 20 {
+		return s[0:20]
+	}
+	return s
+}
+
+func codeBlock(s string) string {
+	return "```\n" + strings.ReplaceAll(s, "```", "'''") + "\n```"
+}
+
+func templateFuncs(catalog *i18n.Catalog) template.FuncMap {
+	return template.FuncMap{
+		"ShortID": shortID,
+		// ShortDigest "sha256:4f2a1c9e8b7d6a5f..." -> "4f2a1c9e8b7d"
+		"ShortDigest": shortDigest,
+		"WrapCode": func(s string) string {
+			return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+		},
+		// CodeBlock wraps logs in a markdown code block
+		"CodeBlock": codeBlock,
+		// T "template.exit_code" translates a catalog key
+		"T": func(key string, args ...any) string {
+			return catalog.T(key, args...)
+		},
+		"ActionName": func(s string) string {
+			if name, ok := catalog.Lookup("action." + s); ok {
+				return name
+			}
+			return s
+		},
+		// ExitCodeDetails "137" -> "Immediate termination SIGKILL"
+		"ExitCodeDetails": func(code string) string {
+			details, _ := catalog.Lookup("exit_code." + code)
+			return details
+		},
+		"Duration": func(s string) string {
+			duration, err := time.ParseDuration(s + "s")
+			if err == nil {
+				return catalog.Duration(duration)
+			}
+			return s + "s"
+		},
+		// Bytes 536870912 -> "512MiB"
+		"Bytes": formatBytes,
+		"ServerInfo": func(info *ServerInfo) string {
+			return info.Text(catalog)
+		},
+		// InfoLines .ServerInfo lists the report as label and value pairs
+		"InfoLines": func(info *ServerInfo) []InfoLine {
+			return info.Lines(catalog)
+		},
+		"DiskReport": func(report *DiskReport) string {
+			return report.Text(catalog)
+		},
+		// MetricValue "reclaimable" 1073741824 -> "1GiB", MetricValue "cpu" 97.53 -> "97.5"
+		"MetricValue": formatMetric,
+		// Label .Labels "com.example.team"
+		"Label": func(labels map[string]string, key string) string {
+			return labels[key]
+		},
+		// FormatTime .Time "2006-01-02 15:04" (RFC3339 when layout is omitted)
+		"FormatTime": func(unix int64, layout ...string) string {
+			format := time.RFC3339
+			if len(layout) > 0 {
+				format = layout[0]
+			}
+			return time.Unix(unix, 0).Format(format)
+		},
+		"Hostname": localHostname,
+		// PastTense "die" -> "stopped"
+		"PastTense": func(action string) string {
+			if summary, ok := catalog.Lookup("action_past." + action); ok {
+				return summary
+			}
+			return action
+		},
+		// ansi colors
+		"Red":            func() string { return Red },
+		"Green":          func() string { return Green },
+		"Yellow":         func() string { return Yellow },
+		"Blue":           func() string { return Blue },
+		"Magenta":        func() string { return Magenta },
+		"Cyan":           func() string { return Cyan },
+		"Gray":           func() string { return Gray },
+		"White":          func() string { return White },
+		"Reset":          func() string { return Reset },
+		"EscapeHTML":     EscapeHTMLTelegram,
+		"EscapeMarkdown": EscapeMarkdownReservedChars,
+	}
+}
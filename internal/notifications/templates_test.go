@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/i18n"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestTemplates_WithFile(t *testing.T) {
+	dir := t.TempDir()
+	event := Event{
+		Type:   "container",
+		Action: "die",
+		Name:   "api",
+		Time:   1700000000,
+		Labels: map[string]string{"team": "payments"},
+	}
+
+	t.Run("overrides a single format", func(t *testing.T) {
+		path := writeTemplate(t, dir, "text.tmpl",
+			`{{.Name}} {{ActionName .Action}} team={{Label .Labels "team"}} in {{FormatTime .Time "2006"}}`)
+
+		templates, err := DefaultTemplates().WithFile(FormatText, path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "api stop team=payments in 2023", templates.Render(FormatText, event))
+		// other formats are untouched
+		assert.Equal(t, DefaultTemplates().Render(FormatHTML, event), templates.Render(FormatHTML, event))
+		// defaults are not modified
+		assert.Equal(t, "container stop api ()", DefaultTemplates().Render(FormatText, event))
+	})
+
+	t.Run("parse error points at the line", func(t *testing.T) {
+		path := writeTemplate(t, dir, "broken.tmpl", "{{.Name}}\n{{NoSuchFunc .Name}}")
+
+		_, err := DefaultTemplates().WithFile(FormatText, path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2:")
+		assert.Contains(t, err.Error(), "NoSuchFunc")
+	})
+
+	t.Run("execution error is caught at load time", func(t *testing.T) {
+		path := writeTemplate(t, dir, "field.tmpl", "{{.Name}}\n{{.NoSuchField}}")
+
+		_, err := DefaultTemplates().WithFile(FormatMarkdown, path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2:")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := DefaultTemplates().WithFile(FormatText, filepath.Join(dir, "missing.tmpl"))
+		require.Error(t, err)
+	})
+}
+
+func TestTemplates_WithDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "text.tmpl", "global {{.Name}}")
+	writeTemplate(t, dir, "telegram.html.tmpl", "<b>{{.Name}}</b> on {{Hostname}}")
+
+	global, err := DefaultTemplates().WithDir(dir, "")
+	require.NoError(t, err)
+
+	telegram, err := global.WithDir(dir, "telegram.")
+	require.NoError(t, err)
+
+	event := Event{Type: "container", Action: "start", Name: "api"}
+	assert.Equal(t, "global api", global.Render(FormatText, event))
+	assert.Equal(t, "global api", telegram.Render(FormatText, event))
+	assert.Contains(t, telegram.Render(FormatHTML, event), "<b>api</b> on ")
+	assert.NotContains(t, global.Render(FormatHTML, event), "<b>api</b>")
+}
+
+func TestNotifierTemplatesFromURL(t *testing.T) {
+	path := writeTemplate(t, t.TempDir(), "md.tmpl", "custom {{.Name}}")
+
+	n, err := NewNotifierFromURL("discord://1234/abcd?template_markdown=" + path)
+	require.NoError(t, err)
+	d := n.(*DiscordNotifier)
+	assert.Equal(t, "custom api", d.render(FormatMarkdown, Event{Name: "api"}))
+
+	n, err = NewNotifierFromURL("json://example.com/hook?a=1&template_text=" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/hook?a=1", n.(*JSONNotifier).url)
+
+	_, err = NewNotifierFromURL("discord://1234/abcd?template_html=/does/not/exist")
+	require.Error(t, err)
+}
+
+func TestTemplates_WithCatalog(t *testing.T) {
+	ru, err := i18n.Load("ru")
+	require.NoError(t, err)
+	templates := DefaultTemplates().WithCatalog(ru)
+
+	event := Event{Type: "container", Action: "die", Name: "api", Image: "nginx", ExitCode: "137", ExecDuration: "3723"}
+	assert.Equal(t,
+		`container остановка api (nginx) (через 1 ч 2 мин 3 с) Код выхода: 137 "Немедленное завершение SIGKILL"`,
+		templates.Render(FormatText, event))
+	// defaults stay in English
+	assert.Equal(t,
+		`container stop api (nginx) (after 1h2m3s) Exit code: 137 "Immediate termination SIGKILL"`,
+		DefaultTemplates().Render(FormatText, event))
+
+	batch := templates.RenderBatch(FormatText, []Event{
+		{Type: "container", Action: "die", Name: "api", Project: "shop", Service: "api", ExitCode: "1"},
+		{Type: "container", Action: "die", Name: "web", Project: "shop", Service: "web", ExitCode: "0"},
+	})
+	assert.Equal(t, "проект shop: 2 остановлено (api код 1)\n"+
+		`container остановка api () shop::api Код выхода: 1 "Ошибка приложения"`, batch)
+
+	info := Event{Type: "Server info", Message: "Docker version: 27", ServerInfo: &ServerInfo{Version: "27.0.0", CPUs: 4, MemoryMB: 2048}}
+	assert.Contains(t, templates.Render(FormatHTML, info), "<b>Версия Docker:</b> 27.0.0\n<b>Хост Docker:</b>")
+	assert.Contains(t, templates.Render(FormatText, info), "Память: 2048 МБ")
+	assert.Equal(t, "Информация о сервере", batchSummary(ru, []Event{info}))
+	assert.Equal(t, "Контейнеров остановлено: 2 на host-x", digestSubject(ru, []Event{event, event}, "host-x"))
+
+	// overrides loaded afterwards use the same catalog
+	path := writeTemplate(t, t.TempDir(), "text.tmpl", `{{ActionName .Action}} {{T "template.exit_code"}}`)
+	custom, err := templates.WithFile(FormatText, path)
+	require.NoError(t, err)
+	assert.Equal(t, "остановка Код выхода", custom.Render(FormatText, event))
+}
+
+func TestNotifierLocaleFromURL(t *testing.T) {
+	n, err := NewNotifierFromURL("tgram://111:xxx/12345?locale=ru-RU")
+	require.NoError(t, err)
+	assert.Equal(t, "ru", n.(*TelegramNotifier).catalog().Locale())
+
+	_, err = NewNotifierFromURL("tgram://111:xxx/12345?locale=xx")
+	require.Error(t, err)
+}
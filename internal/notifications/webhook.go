@@ -0,0 +1,68 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// JSONNotifier posts every event as a JSON object to an HTTP endpoint.
+type JSONNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewJSONNotifier(url string) *JSONNotifier {
+	return &JSONNotifier{
+		url:    url,
+		client: &http.Client{},
+	}
+}
+
+func (j *JSONNotifier) Notify(ctx context.Context, event Event, debug bool) error {
+	return postJSON(ctx, j.client, j.url, event, debug)
+}
+
+func (j *JSONNotifier) NotifyMultiple(ctx context.Context, events []Event, debug bool) error {
+	var errs []error
+	for _, e := range events {
+		if err := j.Notify(ctx, e, debug); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any, debug bool) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	if debug {
+		fmt.Printf("Posting JSON %s\n", body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status code %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// a wednesday
+	now := time.Date(2024, 5, 29, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 29, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 5, 30, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2024, 5, 30, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * *", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"18,20 10 * * *", time.Date(2024, 5, 29, 10, 18, 0, 0, time.UTC)},
+		// either day matches when both are restricted
+		{"0 0 1 * 4", time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC)},
+		// a step over all days is unrestricted, both have to match
+		{"0 0 */2 * 1", time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * */2", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, s.Next(now))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "@yearly",
+		// days that do not exist in the months
+		"0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestSchedule_RunStops(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		(&Schedule{expr: "never"}).Run(context.Background(), func(ctx context.Context) {
+			t.Error("never scheduled")
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return")
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lotas/docker-alerts/internal/metrics"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, err := io.ReadAll(rec.Result().Body)
+	require.NoError(t, err)
+	return rec.Code, string(body)
+}
+
+func TestServer(t *testing.T) {
+	var readyErr error
+	handler := New(":0", func() error { return readyErr }).Handler()
+
+	code, _ := get(t, handler, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = get(t, handler, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	readyErr = errors.New("event stream closed")
+	code, body := get(t, handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "event stream closed")
+	// liveness doesn't depend on the daemon
+	code, _ = get(t, handler, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	metrics.EventsReceived.WithLabelValues("container", "die").Inc()
+	code, body = get(t, handler, "/metrics")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "# TYPE docker_alerts_events_received_total counter")
+	assert.Contains(t, body, `docker_alerts_events_received_total{action="die",type="container"}`)
+	assert.Contains(t, body, "go_goroutines")
+
+	code, _ = get(t, handler, "/unknown")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -9,9 +9,15 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/docker/docker/api/types/system"
+
 	"github.com/lotas/docker-alerts/internal/config"
 	"github.com/lotas/docker-alerts/internal/docker"
+	"github.com/lotas/docker-alerts/internal/heartbeat"
+	"github.com/lotas/docker-alerts/internal/metrics"
 	"github.com/lotas/docker-alerts/internal/notifications"
+	"github.com/lotas/docker-alerts/internal/schedule"
+	"github.com/lotas/docker-alerts/internal/server"
 )
 
 func main() {
@@ -33,30 +39,75 @@ func startApp(cfg *config.Config) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	dockerClient, err := docker.NewClient()
+	if err := notifications.EnableEvents(cfg.EnableEvents); err != nil {
+		return fmt.Errorf("failed to enable events: %w", err)
+	}
+
+	notifier, readiness, err := notifications.CreateNotifier(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to configure notifiers: %w", err)
+	}
+
+	redactor, err := docker.NewRedactor(cfg.LogRedact)
 	if err != nil {
-		return fmt.Errorf("failed to create Docker client: %w", err)
+		return err
 	}
-	defer dockerClient.Close()
 
-	_, infoStr, err := dockerClient.Info(ctx)
+	hosts, err := connectHosts(cfg, redactor)
 	if err != nil {
-		return fmt.Errorf("failed to get Docker info: %w", err)
+		return err
 	}
+	defer closeHosts(hosts)
 
-	notifier := notifications.CreateNotifier(cfg)
+	var summary *schedule.Schedule
+	if cfg.SummaryCron != "" {
+		if summary, err = schedule.Parse(cfg.SummaryCron); err != nil {
+			return fmt.Errorf("failed to schedule status reports: %w", err)
+		}
+	}
 
-	err0 := notifier.Notify(ctx, notifications.Event{
-		Type:    "Server info",
-		Message: infoStr,
-	}, cfg.Debug)
-	if err0 != nil {
-		return fmt.Errorf("Failed to notify: %w", err0)
+	// the local daemon must be up at startup, named hosts are waited for
+	var info system.Info
+	if len(hosts) == 1 && hosts[0].name == "" {
+		info, err = hosts[0].source.Info(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get Docker info: %w", err)
+		}
 	}
 
-	eventStream, err := dockerClient.StreamEvents(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start event stream: %w", err)
+	out := make(chan notifications.Event)
+	for _, h := range hosts {
+		h.watch(cfg, info)
+		go h.run(ctx, cfg, info, summary, out)
+	}
+
+	if cfg.HeartbeatURL != "" {
+		var opts []heartbeat.Option
+		if cfg.HeartbeatFailURL != "" {
+			opts = append(opts, heartbeat.WithFailURL(cfg.HeartbeatFailURL))
+		}
+		pinger, err := heartbeat.New(cfg.HeartbeatURL, cfg.HeartbeatInterval(), streamStatus(hosts), opts...)
+		if err != nil {
+			return err
+		}
+		go pinger.Run(ctx)
+	}
+
+	if cfg.HTTPAddr != "" {
+		srv := server.New(cfg.HTTPAddr, func() error {
+			if err := streamStatus(hosts)(); err != nil {
+				return fmt.Errorf("event stream: %w", err)
+			}
+			if err := readiness.Err(); err != nil {
+				return fmt.Errorf("no notifier can send: %w", err)
+			}
+			return nil
+		})
+		go func() {
+			if err := srv.Run(ctx); err != nil {
+				fmt.Printf("HTTP server stopped: %v\n", err)
+			}
+		}()
 	}
 
 	// graceful shutdown
@@ -65,16 +116,13 @@ func startApp(cfg *config.Config) error {
 
 	for {
 		select {
-		case event := <-eventStream.Events:
-			evt := notifications.NewEventFromDocker(event)
-			if evt.ShouldNotify(cfg.Debug) {
-				err := notifier.Notify(ctx, evt, cfg.Debug)
-				if err != nil {
-					fmt.Printf("Error sending event %+v", err)
-				}
+		case evt := <-out:
+			if err := notifier.Notify(ctx, evt, cfg.Debug); err != nil {
+				metrics.EventsFailed.WithLabelValues(evt.Type, metricAction(evt.Action)).Inc()
+				fmt.Printf("Error sending event %+v", err)
+				continue
 			}
-		case err := <-eventStream.Errors:
-			fmt.Printf("Error receiving event: %v\n", err)
+			metrics.EventsAccepted.WithLabelValues(evt.Type, metricAction(evt.Action)).Inc()
 		case <-sigChan:
 			fmt.Println("Shutting down...")
 			return nil